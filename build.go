@@ -0,0 +1,137 @@
+// Copyright (c) 2025 Nikita Kamenev
+// Licensed under the MIT License. See LICENSE file in the project root for details.
+package suffixarr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"unsafe"
+)
+
+var (
+	// ErrEmpty is returned when there is no text or no strings to index.
+	ErrEmpty = errors.New("suffixarr: empty input")
+	// ErrTooLarge is returned when the input does not fit into the positions of the
+	// suffix array, or its construction would exceed the memory budget.
+	ErrTooLarge = errors.New("suffixarr: input too large")
+)
+
+// WithMemoryBudget limits the memory construction may allocate to the given number
+// of bytes, including the suffix array but not the input text. The requirement is
+// estimated from the length and character range of the text and the parallelism
+// before anything is allocated, and is an upper bound: the build may need less. Constructors returning
+// an error fail with ErrTooLarge when the budget would be exceeded; others ignore it.
+func WithMemoryBudget(bytes int64) Option {
+	return func(o *options) {
+		o.budget = bytes
+	}
+}
+
+// reserve accounts n bytes against the memory budget, if any, and fails with
+// ErrTooLarge once the bytes reserved so far exceed it.
+func (o *options) reserve(n int64) error {
+	if o.budget <= 0 {
+		return nil
+	}
+	o.used += n
+	if o.used > o.budget {
+		return fmt.Errorf("%w: construction needs up to %d bytes, over the memory budget of %d", ErrTooLarge, o.used, o.budget)
+	}
+	return nil
+}
+
+// maxLen returns the length of the longest text addressable by positions of type P.
+func maxLen[P position]() int {
+	var p P
+	if unsafe.Sizeof(p) == 4 {
+		return math.MaxInt32
+	}
+	return math.MaxInt
+}
+
+// buildMemory returns an upper bound of the bytes SA-IS allocates for the text
+// with the given number of workers, including the suffix array. Summary strings
+// are sorted in place, but each recursion level may allocate buckets for up to
+// half the length of the level above it, adding up to twice the text length. On
+// top of that the text itself needs buckets for its character range, or dense
+// ranks and their buckets if its alphabet is sparse.
+func buildMemory[P position, T Symbol](text []T, workers int) int64 {
+	n := int64(len(text))
+	if n == 0 {
+		return 0
+	}
+	size := int64(unsafe.Sizeof(P(0)))
+	minChar, maxChar := slices.Min(text), slices.Max(text)
+	span := uint64(int64(maxChar) - int64(minChar))
+	mem := 3 * n // Suffix array and buckets of summary strings.
+	switch {
+	case span < 256:
+		mem += 2 * 256
+	case span < uint64(n):
+		mem += 2 * (int64(span) + 1)
+	default:
+		mem += 3 * n // Ranks and buckets for at most n distinct characters.
+	}
+	mem *= size
+	if workers <= 1 {
+		return mem
+	}
+	// Each level split between workers also counts characters per chunk, twice in
+	// arrays of up to a quarter of its length, flags LMS substrings that differ from
+	// their predecessors, and reads blocks of suffixes with the two characters before
+	// each for induced sorting. Goroutines add a little per chunk.
+	block := int64(workers) * minParallelLen / 4
+	sym := int64(unsafe.Sizeof(minChar))
+	for m := n; m >= minParallelLen; m /= 2 {
+		mem += m/2*size + m/2 + min(block, m)*(size+2*sym) + m/64 + 1024*int64(workers)
+		sym = size // Summary strings are of positions.
+	}
+	return mem
+}
+
+// Build creates a suffix array for the given text like New, but validates the input.
+// It returns ErrEmpty for an empty text and ErrTooLarge for a text longer than
+// math.MaxInt32 characters or one exceeding the memory budget of WithMemoryBudget.
+func Build(text []int32, opts ...Option) (*SuffixArray, error) {
+	if len(text) == 0 {
+		return nil, ErrEmpty
+	}
+	sa, err := build[int32](text, nil, makeOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	return &SuffixArray{suffixArray[int32, int32]{text: text, sa: sa}}, nil
+}
+
+// BuildGSA creates a generalized suffix array from strings like NewGSA, but validates
+// the input. It returns ErrEmpty if there are no strings and ErrTooLarge if the
+// concatenated strings are longer than math.MaxInt32 characters or exceed the
+// memory budget of WithMemoryBudget.
+func BuildGSA(src []string, opts ...Option) (*GSA, error) {
+	if len(src) == 0 {
+		return nil, ErrEmpty
+	}
+	src32, sz := runes(src)
+	return buildGSA(src32, sz, makeOptions(opts))
+}
+
+// BuildGSA_32 creates a generalized suffix array from int32 slices like NewGSA_32,
+// validating the input like BuildGSA.
+func BuildGSA_32(src [][]int32, opts ...Option) (*GSA, error) {
+	if len(src) == 0 {
+		return nil, ErrEmpty
+	}
+	return buildGSA(src, totalLen(src), makeOptions(opts))
+}
+
+// buildGSA builds the generalized suffix array of validated strings.
+func buildGSA(src [][]int32, strNum int, o *options) (*GSA, error) {
+	sep, hole := separator(src, strNum)
+	gsa, err := newGSA[int32](src, strNum, sep, hole, o)
+	if err != nil {
+		return nil, err
+	}
+	return &GSA{gsa}, nil
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2025 Nikita Kamenev
+// Licensed under the MIT License. See LICENSE file in the project root for details.
+package suffixarr
+
+// Builder constructs suffix arrays, keeping the scratch buffers of SA-IS between
+// builds. Once they have grown to fit the largest text built, building into a
+// long enough dst allocates nothing, unless WithParallelism splits the text
+// between goroutines: each parallel phase then allocates its goroutines and
+// their per-chunk buffers. The zero value is ready to use, which lets idle
+// builders be kept in a sync.Pool. A Builder is not safe for concurrent use.
+type Builder struct {
+	opts options
+}
+
+// NewBuilder creates a builder applying opts to every build.
+func NewBuilder(opts ...Option) *Builder {
+	return &Builder{opts: *uncheckedOptions(opts)}
+}
+
+// Build constructs the suffix array of text into dst, reusing its capacity if it
+// can hold len(text) positions, and returns it. It panics if the text is too large.
+func (b *Builder) Build(text, dst []int32) []int32 {
+	if cap(dst) < len(text) {
+		dst = make([]int32, len(text))
+	} else {
+		dst = dst[:len(text)]
+		clear(dst)
+	}
+	o := &b.opts
+	o.err, o.depth, o.reuse = nil, -1, true
+	sa, err := build(text, dst, o)
+	if err != nil {
+		panic(err)
+	}
+	return sa
+}
+
+// Reset releases the scratch buffers while keeping the options, so that a builder
+// that handled an unusually large text does not hold on to its memory.
+func (b *Builder) Reset() {
+	b.opts.data, b.opts.rank = nil, nil
+}
+
+// scratch returns buf if it holds at least n positions, or else the buffer kept by
+// a Builder if that does. Otherwise it allocates n positions, which a Builder keeps.
+func scratch[P position](o *options, kept *any, buf []P, n int) []P {
+	if len(buf) < n {
+		buf, _ = (*kept).([]P)
+	}
+	if len(buf) < n {
+		buf = make([]P, n)
+		if o.reuse {
+			*kept = buf
+		}
+	}
+	return buf
+}
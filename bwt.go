@@ -0,0 +1,96 @@
+// Copyright (c) 2025 Nikita Kamenev
+// Licensed under the MIT License. See LICENSE file in the project root for details.
+package suffixarr
+
+import "slices"
+
+// BWT computes the Burrows–Wheeler transform of text using the suffix array.
+// The text is treated as terminated by an implicit sentinel smaller than every
+// character. The sentinel is not stored in bwt; instead, primary reports the row
+// of the sorted rotation matrix where it would appear, so len(bwt) == len(text).
+// For an empty text, BWT returns an empty slice and primary 0.
+func BWT(text []int32) (bwt []int32, primary int) {
+	n := len(text)
+	if n == 0 {
+		return []int32{}, 0
+	}
+	// Rewrite the suffix array in place with the preceding characters.
+	bwt = sais[int32](text)
+	for i := 0; i < n; i++ {
+		if j := bwt[i]; j == 0 {
+			// Suffix 0 is preceded by the sentinel; keep the last character in its place.
+			primary = i + 1
+			bwt[i] = text[n-1]
+		} else {
+			bwt[i] = text[j-1]
+		}
+	}
+	// The row of the empty suffix sorts first and ends with the last character,
+	// so move it to the front, dropping the sentinel row.
+	last := bwt[primary-1]
+	copy(bwt[1:primary], bwt[:primary-1])
+	bwt[0] = last
+	return bwt, primary
+}
+
+// InverseBWT reconstructs the text from its Burrows–Wheeler transform and the
+// primary index returned by BWT. It panics if primary is out of range [1, len(bwt)]
+// for a non-empty transform.
+func InverseBWT(bwt []int32, primary int) []int32 {
+	n := len(bwt)
+	if n == 0 {
+		return []int32{}
+	}
+	if primary < 1 || primary > n {
+		panic("suffixarr: primary index out of range")
+	}
+	alphabet := alphabetOf(bwt)
+	// Count characters; bucket 0 is reserved for the sentinel row.
+	lf := make([]int32, n+1)
+	buckets := make([]int32, len(alphabet)+1)
+	for i, j := 0, 0; i <= n; i++ {
+		if i == primary {
+			continue
+		}
+		c, _ := slices.BinarySearch(alphabet, bwt[j])
+		lf[i] = int32(c)
+		buckets[c+1]++
+		j++
+	}
+	// Convert counts to first rows of each character, after the sentinel row.
+	var offset int32 = 1
+	for c := 1; c < len(buckets); c++ {
+		offset, buckets[c] = offset+buckets[c], offset
+	}
+	// Replace dense ranks with the last-to-first mapping of each row.
+	for i := 0; i <= n; i++ {
+		if i == primary {
+			lf[i] = 0
+			continue
+		}
+		c := lf[i] + 1
+		lf[i] = buckets[c]
+		buckets[c]++
+	}
+	// Walk the mapping backwards from the sentinel row to restore the text.
+	text := make([]int32, n)
+	var row int32
+	for k := n - 1; k >= 0; k-- {
+		if int(row) < primary {
+			text[k] = bwt[row]
+		} else {
+			text[k] = bwt[row-1]
+		}
+		row = lf[row]
+	}
+	return text
+}
+
+// alphabetOf returns the distinct characters of text in ascending order.
+// The position of a character in the result is its dense rank.
+func alphabetOf(text []int32) []int32 {
+	alphabet := slices.Clone(text)
+	slices.Sort(alphabet)
+	// Copy the result so the text-sized sorting buffer can be released.
+	return slices.Clone(slices.Compact(alphabet))
+}
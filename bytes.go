@@ -0,0 +1,95 @@
+// Copyright (c) 2025 Nikita Kamenev
+// Licensed under the MIT License. See LICENSE file in the project root for details.
+package suffixarr
+
+import "iter"
+
+// Bytes are stored one higher in the text of a generalized suffix array over
+// bytes, leaving zero to separate strings.
+const (
+	byteSep  uint16 = 0
+	byteHole uint16 = 0x100
+)
+
+// BytesSuffixArray holds a byte text and its suffix array.
+// The suffix array is built directly over the bytes using the small-alphabet
+// SA-IS path, without expanding the text to int32 characters.
+type BytesSuffixArray struct {
+	suffixArray[byte, int32]
+}
+
+// NewBytes creates a suffix array for the given byte text.
+func NewBytes(text []byte, opts ...Option) *BytesSuffixArray {
+	return &BytesSuffixArray{suffixArray[byte, int32]{text: text, sa: sais[int32](text, opts...)}}
+}
+
+// BytesGSA represents a generalized suffix array for multiple byte strings.
+// Strings are concatenated into 16-bit characters, half the size of the
+// int32 text used by GSA, with a separator below every byte.
+type BytesGSA struct {
+	generalizedSA[uint16, int32]
+}
+
+// NewGSABytes creates a generalized suffix array from byte slices.
+func NewGSABytes(src [][]byte, opts ...Option) *BytesGSA {
+	if len(src) == 0 {
+		return nil
+	}
+	gsa := mustGSA(newGSA[int32](src, totalLen(src), byteSep, byteHole, uncheckedOptions(opts)))
+	return &BytesGSA{gsa}
+}
+
+// widen converts a byte pattern to the 16-bit characters of the text of a BytesGSA.
+func widen(b []byte) []uint16 {
+	res := make([]uint16, len(b))
+	for i, c := range b {
+		res[i] = uint16(c) + 1
+	}
+	return res
+}
+
+// Lookup finds prefix occurrences in the generalized suffix array in lexicographical order.
+func (gsa *BytesGSA) Lookup(prefix []byte) []Occurrence[int32] {
+	return gsa.lookup(widen(prefix))
+}
+
+// Count returns the number of prefix occurrences in the generalized suffix array.
+func (gsa *BytesGSA) Count(prefix []byte) int {
+	return gsa.count(widen(prefix))
+}
+
+// DocumentCount returns the number of strings containing the prefix.
+func (gsa *BytesGSA) DocumentCount(prefix []byte) int {
+	return gsa.documentCount(widen(prefix))
+}
+
+// Range finds occurrences of the suffixes s of the strings with lo <= s < hi in
+// lexicographical order. An empty hi leaves the range unbounded above.
+func (gsa *BytesGSA) Range(lo, hi []byte) []Occurrence[int32] {
+	return gsa.between(widen(lo), widen(hi))
+}
+
+// Occurrences yields prefix occurrences in the generalized suffix array in lexicographical order.
+func (gsa *BytesGSA) Occurrences(prefix []byte) iter.Seq[Occurrence[int32]] {
+	return gsa.occurrences(widen(prefix))
+}
+
+// Documents yields the index of every string containing the prefix once.
+func (gsa *BytesGSA) Documents(prefix []byte) iter.Seq[int32] {
+	return gsa.documents(widen(prefix))
+}
+
+// LookupTextOrder finds prefix occurrences in the generalized suffix array, sorted by text position.
+func (gsa *BytesGSA) LookupTextOrder(prefix []byte) []Index {
+	return gsa.lookupTextOrder(widen(prefix))
+}
+
+// LookupSuffix finds suffix occurrences in the generalized suffix array, sorted by text position.
+func (gsa *BytesGSA) LookupSuffix(suf []byte) []Index {
+	return gsa.lookupSuffix(widen(suf))
+}
+
+// LookupPrefix finds prefix occurrences in the generalized suffix array, sorted by text position.
+func (gsa *BytesGSA) LookupPrefix(prefix []byte) []Index {
+	return gsa.lookupPrefix(widen(prefix))
+}
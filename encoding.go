@@ -0,0 +1,411 @@
+// Copyright (c) 2025 Nikita Kamenev
+// Licensed under the MIT License. See LICENSE file in the project root for details.
+package suffixarr
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"math"
+	"slices"
+)
+
+// Binary format
+//
+// An index is stored as a fixed-size header followed by the payload arrays.
+// All values are little-endian. The header is laid out as follows:
+//
+//	offset  size  field
+//	0       4     magic "SFXA"
+//	4       2     format version
+//	6       1     kind of index (suffix array or generalized suffix array)
+//	7       1     element width in bytes
+//	8       8     text length n
+//	16      8     number of strings (generalized suffix array only)
+//	24      4     CRC-32C of the payload
+//	28      4     CRC-32C of header bytes [0, 28)
+//
+// The payload holds n elements of each array: the text and suffix array, plus
+// string indices for a generalized suffix array, where the index of the leading
+// separator holds the hole. The header size is a multiple of the element width,
+// so arrays stay aligned when the data is memory-mapped.
+
+const (
+	formatMagic   = "SFXA"
+	formatVersion = 1
+	headerSize    = 32
+	chunkSize     = 1 << 16 // Encoding buffer size in bytes.
+)
+
+// Kinds of serialized indexes.
+const (
+	kindSuffixArray uint8 = 1
+	kindGSA         uint8 = 2
+)
+
+var (
+	// ErrFormat is returned when serialized data is not a valid index.
+	ErrFormat = errors.New("suffixarr: invalid index format")
+	// ErrChecksum is returned when serialized data fails checksum verification.
+	ErrChecksum = errors.New("suffixarr: index checksum mismatch")
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+var (
+	_ encoding.BinaryMarshaler   = (*SuffixArray)(nil)
+	_ encoding.BinaryUnmarshaler = (*SuffixArray)(nil)
+	_ io.WriterTo                = (*SuffixArray)(nil)
+	_ io.ReaderFrom              = (*SuffixArray)(nil)
+	_ encoding.BinaryMarshaler   = (*GSA)(nil)
+	_ encoding.BinaryUnmarshaler = (*GSA)(nil)
+	_ io.WriterTo                = (*GSA)(nil)
+	_ io.ReaderFrom              = (*GSA)(nil)
+)
+
+// header describes a serialized index.
+type header struct {
+	version uint16
+	kind    uint8
+	width   uint8
+	length  uint64 // Text length.
+	strNum  uint64 // Number of strings in a generalized suffix array.
+	crc     uint32 // Payload checksum.
+}
+
+// arrays returns the number of payload arrays stored for the kind of index.
+func (h *header) arrays() int {
+	if h.kind == kindGSA {
+		return 3
+	}
+	return 2
+}
+
+// encode serializes the header.
+func (h *header) encode() []byte {
+	b := make([]byte, headerSize)
+	copy(b, formatMagic)
+	binary.LittleEndian.PutUint16(b[4:], h.version)
+	b[6], b[7] = h.kind, h.width
+	binary.LittleEndian.PutUint64(b[8:], h.length)
+	binary.LittleEndian.PutUint64(b[16:], h.strNum)
+	binary.LittleEndian.PutUint32(b[24:], h.crc)
+	binary.LittleEndian.PutUint32(b[28:], crc32.Checksum(b[:28], castagnoli))
+	return b
+}
+
+// decodeHeader parses and validates a header of the expected kind.
+func decodeHeader(b []byte, kind uint8) (h header, err error) {
+	if string(b[:4]) != formatMagic {
+		return h, fmt.Errorf("%w: bad magic", ErrFormat)
+	}
+	if binary.LittleEndian.Uint32(b[28:]) != crc32.Checksum(b[:28], castagnoli) {
+		return h, fmt.Errorf("%w: header", ErrChecksum)
+	}
+	h = header{
+		version: binary.LittleEndian.Uint16(b[4:]),
+		kind:    b[6],
+		width:   b[7],
+		length:  binary.LittleEndian.Uint64(b[8:]),
+		strNum:  binary.LittleEndian.Uint64(b[16:]),
+		crc:     binary.LittleEndian.Uint32(b[24:]),
+	}
+	switch {
+	case h.version == 0 || h.version > formatVersion:
+		return h, fmt.Errorf("%w: unsupported version %d", ErrFormat, h.version)
+	case h.kind != kind:
+		return h, fmt.Errorf("%w: unexpected index kind %d", ErrFormat, h.kind)
+	case h.width != 4:
+		return h, fmt.Errorf("%w: unsupported element width %d", ErrFormat, h.width)
+	case h.length > math.MaxInt32:
+		return h, fmt.Errorf("%w: text length %d out of range", ErrFormat, h.length)
+	case h.kind == kindGSA && (h.strNum == 0 || h.strNum >= h.length):
+		return h, fmt.Errorf("%w: %d strings in text of length %d", ErrFormat, h.strNum, h.length)
+	}
+	return h, nil
+}
+
+// encodeChunks encodes arrays in little-endian order, passing each filled
+// chunk of buf to fn.
+func encodeChunks(buf []byte, fn func([]byte) error, arrays ...[]int32) error {
+	var k int
+	for _, arr := range arrays {
+		for _, v := range arr {
+			if k == len(buf) {
+				if err := fn(buf); err != nil {
+					return err
+				}
+				k = 0
+			}
+			binary.LittleEndian.PutUint32(buf[k:], uint32(v))
+			k += 4
+		}
+	}
+	if k > 0 {
+		return fn(buf[:k])
+	}
+	return nil
+}
+
+// writeIndex writes the header and payload arrays of an index to w.
+func writeIndex(w io.Writer, h header, arrays ...[]int32) (int64, error) {
+	buf := make([]byte, chunkSize)
+	// Checksum the payload first, since the header precedes it.
+	crc := crc32.New(castagnoli)
+	encodeChunks(buf, func(b []byte) error {
+		crc.Write(b)
+		return nil
+	}, arrays...)
+	h.version, h.width, h.crc = formatVersion, 4, crc.Sum32()
+	var written int64
+	write := func(b []byte) error {
+		n, err := w.Write(b)
+		written += int64(n)
+		return err
+	}
+	if err := write(h.encode()); err != nil {
+		return written, err
+	}
+	err := encodeChunks(buf, write, arrays...)
+	return written, err
+}
+
+// readIndex reads an index of the expected kind from r. It returns the header
+// and a buffer holding the payload arrays back to back. The header does not
+// size the buffer unless size, the length of the input or -1 if unknown, shows
+// that the payload is all there; otherwise the buffer grows as data arrives.
+func readIndex(r io.Reader, kind uint8, size int64) (header, []int32, int64, error) {
+	b := make([]byte, headerSize)
+	read, err := io.ReadFull(r, b)
+	if err != nil {
+		return header{}, nil, int64(read), unexpectedEOF(err)
+	}
+	h, err := decodeHeader(b, kind)
+	if err != nil {
+		return h, nil, int64(read), err
+	}
+	// Size the payload in 64 bits, since it may not fit an int on 32-bit targets.
+	total64 := h.length * uint64(h.arrays())
+	if total64 > math.MaxInt/4 {
+		return h, nil, int64(read), fmt.Errorf("%w: payload of %d elements out of range", ErrFormat, total64)
+	}
+	total := int(total64)
+	capacity := min(total, chunkSize/4)
+	if size >= 0 {
+		if int64(total)*4 > size-headerSize {
+			return h, nil, int64(read), unexpectedEOF(io.ErrUnexpectedEOF)
+		}
+		capacity = total
+	}
+	payload := make([]int32, 0, capacity)
+	crc := crc32.New(castagnoli)
+	b = make([]byte, chunkSize)
+	// Decode payload in chunks while updating the checksum.
+	for k := 0; k < total; k = len(payload) {
+		chunk := b[:min(len(b), (total-k)*4)]
+		n, err := io.ReadFull(r, chunk)
+		read += n
+		if err != nil {
+			return h, nil, int64(read), unexpectedEOF(err)
+		}
+		payload = slices.Grow(payload, len(chunk)/4)[:k+len(chunk)/4]
+		decodeChunk(crc, chunk, payload[k:])
+	}
+	if crc.Sum32() != h.crc {
+		return h, nil, int64(read), fmt.Errorf("%w: payload", ErrChecksum)
+	}
+	return h, payload, int64(read), nil
+}
+
+// decodeChunk decodes little-endian values from chunk into dst,
+// updating the checksum.
+func decodeChunk(crc hash.Hash32, chunk []byte, dst []int32) {
+	crc.Write(chunk)
+	for i := range len(chunk) / 4 {
+		dst[i] = int32(binary.LittleEndian.Uint32(chunk[i*4:]))
+	}
+}
+
+// unexpectedEOF reports truncated input as a format error.
+func unexpectedEOF(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return fmt.Errorf("%w: %w", ErrFormat, io.ErrUnexpectedEOF)
+	}
+	return err
+}
+
+// validateSA checks that sa is a permutation of text positions whose first
+// characters are in ascending order.
+func validateSA(text, sa []int32) error {
+	if len(sa) != len(text) {
+		return fmt.Errorf("%w: suffix array length mismatch", ErrFormat)
+	}
+	seen := newBitVector(len(sa))
+	for i, j := range sa {
+		if j < 0 || int(j) >= len(sa) || seen.get(int(j)) {
+			return fmt.Errorf("%w: suffix array is not a permutation", ErrFormat)
+		}
+		seen.set(int(j))
+		if i > 0 && text[sa[i-1]] > text[j] {
+			return fmt.Errorf("%w: suffix array is not sorted", ErrFormat)
+		}
+	}
+	return nil
+}
+
+// unmarshal decodes a whole index from data, rejecting trailing bytes.
+func unmarshal(data []byte, kind uint8) (header, []int32, error) {
+	r := bytes.NewReader(data)
+	h, payload, _, err := readIndex(r, kind, int64(len(data)))
+	if err == nil && r.Len() > 0 {
+		err = fmt.Errorf("%w: %d trailing bytes", ErrFormat, r.Len())
+	}
+	return h, payload, err
+}
+
+// marshal encodes a whole index into a byte slice.
+func marshal(h header, arrays ...[]int32) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Grow(headerSize + len(arrays)*len(arrays[0])*4)
+	_, err := writeIndex(&buf, h, arrays...)
+	return buf.Bytes(), err
+}
+
+// WriteTo writes the binary encoding of the suffix array to w.
+// It implements io.WriterTo.
+func (sa *SuffixArray) WriteTo(w io.Writer) (int64, error) {
+	return writeIndex(w, header{kind: kindSuffixArray, length: uint64(len(sa.text))}, sa.text, sa.sa)
+}
+
+// ReadFrom replaces the suffix array with one decoded from r.
+// It implements io.ReaderFrom.
+func (sa *SuffixArray) ReadFrom(r io.Reader) (int64, error) {
+	h, payload, n, err := readIndex(r, kindSuffixArray, -1)
+	if err != nil {
+		return n, err
+	}
+	return n, sa.restore(h, payload)
+}
+
+// MarshalBinary encodes the suffix array together with its text.
+// It implements encoding.BinaryMarshaler.
+func (sa *SuffixArray) MarshalBinary() ([]byte, error) {
+	return marshal(header{kind: kindSuffixArray, length: uint64(len(sa.text))}, sa.text, sa.sa)
+}
+
+// UnmarshalBinary replaces the suffix array with one decoded from data.
+// It implements encoding.BinaryUnmarshaler.
+func (sa *SuffixArray) UnmarshalBinary(data []byte) error {
+	h, payload, err := unmarshal(data, kindSuffixArray)
+	if err != nil {
+		return err
+	}
+	return sa.restore(h, payload)
+}
+
+// restore validates decoded arrays and installs them into the suffix array.
+func (sa *SuffixArray) restore(h header, payload []int32) error {
+	n := int(h.length)
+	text, arr := payload[:n:n], payload[n:]
+	if err := validateSA(text, arr); err != nil {
+		return err
+	}
+	*sa = SuffixArray{suffixArray[int32, int32]{text: text, sa: arr}}
+	return nil
+}
+
+// WriteTo writes the binary encoding of the generalized suffix array to w.
+// It implements io.WriterTo.
+func (gsa *GSA) WriteTo(w io.Writer) (int64, error) {
+	return writeIndex(w, gsa.header(), gsa.text, gsa.sa, gsa.strIdx)
+}
+
+// ReadFrom replaces the generalized suffix array with one decoded from r.
+// It implements io.ReaderFrom.
+func (gsa *GSA) ReadFrom(r io.Reader) (int64, error) {
+	h, payload, n, err := readIndex(r, kindGSA, -1)
+	if err != nil {
+		return n, err
+	}
+	return n, gsa.restore(h, payload)
+}
+
+// MarshalBinary encodes the generalized suffix array together with its text.
+// It implements encoding.BinaryMarshaler.
+func (gsa *GSA) MarshalBinary() ([]byte, error) {
+	return marshal(gsa.header(), gsa.text, gsa.sa, gsa.strIdx)
+}
+
+// UnmarshalBinary replaces the generalized suffix array with one decoded from data.
+// It implements encoding.BinaryUnmarshaler.
+func (gsa *GSA) UnmarshalBinary(data []byte) error {
+	h, payload, err := unmarshal(data, kindGSA)
+	if err != nil {
+		return err
+	}
+	return gsa.restore(h, payload)
+}
+
+// header returns the header describing the generalized suffix array.
+func (gsa *GSA) header() header {
+	return header{kind: kindGSA, length: uint64(len(gsa.text)), strNum: uint64(len(gsa.src))}
+}
+
+// restore validates decoded arrays and rebuilds string metadata of the
+// generalized suffix array.
+func (gsa *GSA) restore(h header, payload []int32) error {
+	n, strNum := int(h.length), int(h.strNum)
+	text, sa, strIdx := payload[:n:n], payload[n:2*n:2*n], payload[2*n:]
+	if err := validateSA(text, sa); err != nil {
+		return err
+	}
+	// The text starts with the separator chosen for the strings, and its index
+	// records the hole.
+	sep, hole := text[0], strIdx[0]
+	if hole < sep || hole == math.MaxInt32 {
+		return fmt.Errorf("%w: hole %d for separator %d", ErrFormat, hole, sep)
+	}
+	src := make([][]int32, strNum)
+	offsets := make([]int32, strNum)
+	// Each string spans positions with its index, the last being a separator.
+	var l int
+	for i := 0; i < strNum; i++ {
+		r := l + 1
+		for r < n && strIdx[r] == int32(i) {
+			r++
+		}
+		if r == l+1 || text[r-1] != sep {
+			return fmt.Errorf("%w: malformed string %d", ErrFormat, i)
+		}
+		src[i] = text[l+1 : r-1 : r-1]
+		if slices.ContainsFunc(src[i], func(c int32) bool { return c <= sep }) {
+			return fmt.Errorf("%w: separator not below string %d", ErrFormat, i)
+		}
+		offsets[i] = int32(l + 1)
+		l = r - 1
+	}
+	if l != n-1 {
+		return fmt.Errorf("%w: string indices out of range", ErrFormat)
+	}
+	// Shifted characters are restored in copies of the strings.
+	if hole > sep {
+		chars := make([]int32, 0, n-strNum-1)
+		for i, s := range src {
+			k := len(chars)
+			for _, c := range s {
+				if c <= hole {
+					c--
+				}
+				chars = append(chars, c)
+			}
+			src[i] = chars[k:len(chars):len(chars)]
+		}
+	}
+	*gsa = GSA{generalizedSA[int32, int32]{src, text, sa, strIdx, offsets, sep, hole, new(documentChain[int32])}}
+	return nil
+}
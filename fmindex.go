@@ -0,0 +1,178 @@
+// Copyright (c) 2025 Nikita Kamenev
+// Licensed under the MIT License. See LICENSE file in the project root for details.
+package suffixarr
+
+import "slices"
+
+// DefaultSampleRate is the suffix array sampling rate used by NewFMIndex
+// when a non-positive rate is given.
+const DefaultSampleRate = 32
+
+// FMIndex is a compressed full-text index built from the Burrows–Wheeler
+// transform of a text. It answers counting queries in O(m log sigma) time for a
+// pattern of length m, independent of the text length, and does not keep the
+// original text or the full suffix array in memory.
+type FMIndex struct {
+	n, rate  int
+	alphabet []int32        // Distinct characters; code c+1 stands for alphabet[c].
+	first    []int32        // First row of each code in the sorted rotation matrix.
+	bwt      *waveletMatrix // Codes of the BWT, code 0 marks the sentinel.
+	sampled  bitVector      // Rows whose suffix position is a multiple of rate.
+	sa       []int32        // Suffix positions of sampled rows, in row order.
+	isa      []int32        // Rows of positions 0, rate, 2*rate, ..., and of the text end.
+}
+
+// NewFMIndex creates an FM-index for the given text. Every rate-th suffix
+// position is sampled: larger rates use less memory but make Locate and Extract
+// slower. If rate is not positive, DefaultSampleRate is used.
+func NewFMIndex(text []int32, rate int) *FMIndex {
+	if rate <= 0 {
+		rate = DefaultSampleRate
+	}
+	n := len(text)
+	sa := sais[int32](text)
+	alphabet := alphabetOf(text)
+	fm := &FMIndex{
+		n:        n,
+		rate:     rate,
+		alphabet: alphabet,
+		first:    make([]int32, len(alphabet)+1),
+		sampled:  newBitVector(n + 1),
+		isa:      make([]int32, (n+rate-1)/rate+1),
+	}
+	// Row 0 holds the empty suffix, preceded by the last character.
+	codes := make([]int32, n+1)
+	if n > 0 {
+		codes[0] = fm.code(text[n-1])
+	}
+	// Fill the remaining rows from the suffix array, sampling as we go.
+	var numSampled int
+	for i := 0; i < n; i++ {
+		row, j := i+1, sa[i]
+		if j > 0 {
+			codes[row] = fm.code(text[j-1])
+		}
+		if int(j)%rate == 0 {
+			fm.sampled.set(row)
+			fm.isa[int(j)/rate] = int32(row)
+			numSampled++
+		}
+	}
+	fm.sampled.build()
+	// Keep suffix positions of sampled rows only.
+	fm.sa = make([]int32, 0, numSampled)
+	for i := 0; i < n; i++ {
+		if int(sa[i])%rate == 0 {
+			fm.sa = append(fm.sa, sa[i])
+		}
+	}
+	// Count codes to find the first row of each one, after the sentinel row.
+	counts := make([]int32, len(alphabet)+1)
+	for _, c := range codes {
+		counts[c]++
+	}
+	var offset int32
+	for c := range counts {
+		fm.first[c] = offset
+		offset += counts[c]
+	}
+	fm.bwt = newWaveletMatrix(codes, len(alphabet)+1)
+	return fm
+}
+
+// code returns the code of character ch, or -1 if it does not occur in the text.
+func (fm *FMIndex) code(ch int32) int32 {
+	c, ok := slices.BinarySearch(fm.alphabet, ch)
+	if !ok {
+		return -1
+	}
+	return int32(c) + 1
+}
+
+// lf maps a row to the row of the suffix one position to the left.
+func (fm *FMIndex) lf(row int) (int, int32) {
+	c, r := fm.bwt.access(row)
+	return int(fm.first[c]) + r, c
+}
+
+// search performs backward search and returns the half-open row range
+// of suffixes starting with the pattern.
+func (fm *FMIndex) search(pattern []int32) (sp, ep int) {
+	if len(pattern) == 0 {
+		return 1, fm.n + 1 // Every suffix but the empty one.
+	}
+	sp, ep = 0, fm.n+1
+	for i := len(pattern) - 1; i >= 0; i-- {
+		c := fm.code(pattern[i])
+		if c < 0 {
+			return 0, 0
+		}
+		sp = int(fm.first[c]) + fm.bwt.rank(c, sp)
+		ep = int(fm.first[c]) + fm.bwt.rank(c, ep)
+		if sp >= ep {
+			return 0, 0
+		}
+	}
+	return sp, ep
+}
+
+// locate returns the text position of the suffix at the given row by walking
+// to the nearest sampled row.
+func (fm *FMIndex) locate(row int) int32 {
+	var steps int32
+	for !fm.sampled.get(row) {
+		row, _ = fm.lf(row)
+		steps++
+	}
+	return fm.sa[fm.sampled.rank1(row)] + steps
+}
+
+// Len returns the length of the indexed text.
+func (fm *FMIndex) Len() int {
+	return fm.n
+}
+
+// Count returns the number of occurrences of the pattern in the text.
+// An empty pattern occurs at every position.
+func (fm *FMIndex) Count(pattern []int32) int {
+	sp, ep := fm.search(pattern)
+	return ep - sp
+}
+
+// Locate finds the positions of all occurrences of the pattern, in the
+// lexicographic order of the suffixes starting there, like SuffixArray.Lookup.
+// Each position costs at most rate steps of the last-to-first mapping.
+func (fm *FMIndex) Locate(pattern []int32) []int32 {
+	sp, ep := fm.search(pattern)
+	res := make([]int32, ep-sp)
+	for row := sp; row < ep; row++ {
+		res[row-sp] = fm.locate(row)
+	}
+	return res
+}
+
+// Extract recovers the text in range [start, end) without the original text.
+// It panics if the range is out of bounds, like slicing would.
+func (fm *FMIndex) Extract(start, end int) []int32 {
+	if start < 0 || end < start || end > fm.n {
+		panic("suffixarr: extract range out of bounds")
+	}
+	// Start from the nearest sampled position at or after end.
+	k := (end + fm.rate - 1) / fm.rate
+	pos := k * fm.rate
+	if pos >= fm.n {
+		pos, k = fm.n, len(fm.isa)-1
+	}
+	row := int(fm.isa[k])
+	res := make([]int32, end-start)
+	// Walk backwards, each row yielding the character before its suffix.
+	for pos > start {
+		var c int32
+		row, c = fm.lf(row)
+		pos--
+		if pos < end {
+			res[pos-start] = fm.alphabet[c-1]
+		}
+	}
+	return res
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2025 Nikita Kamenev
+// Licensed under the MIT License. See LICENSE file in the project root for details.
+package suffixarr
+
+import "iter"
+
+// Occurrences yields the positions of suffixes starting with the prefix in
+// lexicographical order, like Lookup, without allocating a result.
+func (sa *suffixArray[T, P]) Occurrences(prefix []T) iter.Seq[P] {
+	return func(yield func(P) bool) {
+		l, r := bounds(sa.text, sa.sa, sa.lr.Load(), sa.table.Load(), prefix)
+		for _, p := range sa.sa[l:r] {
+			if !yield(p) {
+				return
+			}
+		}
+	}
+}
+
+// Suffixes yields every suffix of the text with its position in lexicographical order.
+func (sa *suffixArray[T, P]) Suffixes() iter.Seq2[P, []T] {
+	return func(yield func(P, []T) bool) {
+		for _, p := range sa.sa {
+			if !yield(p, sa.text[p:]) {
+				return
+			}
+		}
+	}
+}
+
+// Occurrences yields prefix occurrences in the generalized suffix array in
+// lexicographical order, like Lookup, without allocating a result.
+func (gsa *generalizedSA[T, P]) Occurrences(prefix []T) iter.Seq[Occurrence[P]] {
+	prefix, ok := gsa.encode(prefix)
+	if !ok {
+		return func(func(Occurrence[P]) bool) {}
+	}
+	return gsa.occurrences(prefix)
+}
+
+// occurrences is Occurrences for a pattern mapped to the characters of the text.
+func (gsa *generalizedSA[T, P]) occurrences(prefix []T) iter.Seq[Occurrence[P]] {
+	return func(yield func(Occurrence[P]) bool) {
+		l, r := bounds(gsa.text, gsa.sa, nil, nil, prefix)
+		for _, j := range gsa.sa[l:r] {
+			// Skip separators, which only the empty prefix matches.
+			if gsa.text[j] == gsa.sep {
+				continue
+			}
+			str := gsa.strIdx[j]
+			if !yield(Occurrence[P]{str, j - gsa.offsets[str]}) {
+				return
+			}
+		}
+	}
+}
+
+// Documents yields the index of every string containing the prefix once, in the
+// lexicographical order of its first occurrence. Like DocumentCount, the first call
+// links the suffixes of each string.
+func (gsa *generalizedSA[T, P]) Documents(prefix []T) iter.Seq[P] {
+	prefix, ok := gsa.encode(prefix)
+	if !ok {
+		return func(func(P) bool) {}
+	}
+	return gsa.documents(prefix)
+}
+
+// documents is Documents for a pattern mapped to the characters of the text.
+func (gsa *generalizedSA[T, P]) documents(prefix []T) iter.Seq[P] {
+	return func(yield func(P) bool) {
+		if len(gsa.sa) == 0 {
+			return
+		}
+		l, r := bounds(gsa.text, gsa.sa, nil, nil, prefix)
+		prev := gsa.documentChain()
+		for i := l; i < r; i++ {
+			if prev[i] < P(l) && !yield(gsa.strIdx[gsa.sa[i]]) {
+				return
+			}
+		}
+	}
+}
+
+// Suffixes yields every suffix of every string with its occurrence in lexicographical order.
+// Suffixes end with their string and share memory with the generalized suffix array.
+func (gsa *generalizedSA[T, P]) Suffixes() iter.Seq2[Occurrence[P], []T] {
+	return func(yield func(Occurrence[P], []T) bool) {
+		for _, j := range gsa.sa {
+			if gsa.text[j] == gsa.sep {
+				continue
+			}
+			str := gsa.strIdx[j]
+			offset := j - gsa.offsets[str]
+			if !yield(Occurrence[P]{str, offset}, gsa.src[str][offset:]) {
+				return
+			}
+		}
+	}
+}
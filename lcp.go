@@ -0,0 +1,60 @@
+// Copyright (c) 2025 Nikita Kamenev
+// Licensed under the MIT License. See LICENSE file in the project root for details.
+package suffixarr
+
+// kasai computes the longest common prefix (LCP) array for the suffix array sa
+// of text and its inverse rank using Kasai's linear-time algorithm. lcp[i] holds
+// the length of the longest common prefix of suffixes sa[i-1] and sa[i]; lcp[0] is 0.
+// If bounded is set, common prefixes stop at the separator character, so values
+// never run across string boundaries of a generalized suffix array.
+func kasai[T Symbol, P position](text []T, sa, rank []P, sep T, bounded bool) []P {
+	n := len(sa)
+	lcp := make([]P, n)
+	var h int
+	// Visit suffixes in text order, each step reusing h-1 characters
+	// matched for the previous text position.
+	for i := 0; i < n; i++ {
+		r := rank[i]
+		if r == 0 {
+			h = 0
+			continue
+		}
+		j := int(sa[r-1])
+		for i+h < n && j+h < n && text[i+h] == text[j+h] {
+			if bounded && text[i+h] == sep {
+				break
+			}
+			h++
+		}
+		lcp[r] = P(h)
+		if h > 0 {
+			h--
+		}
+	}
+	return lcp
+}
+
+// LCP returns the longest common prefix array of the suffix array.
+// The value at index i is the length of the longest common prefix of the
+// suffixes at ranks i-1 and i; the value at index 0 is 0.
+func (sa *suffixArray[T, P]) LCP() []P {
+	return kasai(sa.text, sa.sa, sa.inverse(), 0, false)
+}
+
+// LCP returns the longest common prefix array of the generalized suffix array.
+// Values are aligned with the suffixes of the strings in the order of Suffixes
+// and Lookup(nil): the value at index i is the length of the longest common
+// prefix of suffixes i-1 and i, which never extends past the end of a string.
+func (gsa *generalizedSA[T, P]) LCP() []P {
+	lcp := kasai(gsa.text, gsa.sa, inverse(gsa.sa), gsa.sep, true)
+	// Separator rows share no prefix with their neighbours, so dropping
+	// them leaves the values of the remaining rows unchanged.
+	n := 0
+	for r, j := range gsa.sa {
+		if gsa.text[j] != gsa.sep {
+			lcp[n] = lcp[r]
+			n++
+		}
+	}
+	return lcp[:n:n]
+}
@@ -0,0 +1,40 @@
+// Copyright (c) 2025 Nikita Kamenev
+// Licensed under the MIT License. See LICENSE file in the project root for details.
+package suffixarr
+
+// Locate returns the rank at which the pattern would be inserted into the suffix
+// array, which is the rank of its first occurrence if it occurs, and the length of
+// the longest prefix of the pattern that occurs in the text.
+func (sa *suffixArray[T, P]) Locate(pattern []T) (rank int, matched int) {
+	rank, _ = bounds(sa.text, sa.sa, sa.lr.Load(), sa.table.Load(), pattern)
+	// The suffixes sharing most of the pattern surround its insertion rank.
+	if rank > 0 {
+		_, matched = comparePrefix(sa.text[sa.sa[rank-1]:], pattern, 0)
+	}
+	if rank < len(sa.sa) {
+		_, k := comparePrefix(sa.text[sa.sa[rank]:], pattern, 0)
+		matched = max(matched, k)
+	}
+	return rank, matched
+}
+
+// Predecessor returns the text position of the greatest suffix less than the
+// pattern, or -1 if there is none. Suffixes starting with the pattern are not less.
+func (sa *suffixArray[T, P]) Predecessor(pattern []T) int {
+	rank, _ := bounds(sa.text, sa.sa, sa.lr.Load(), sa.table.Load(), pattern)
+	if rank == 0 {
+		return -1
+	}
+	return int(sa.sa[rank-1])
+}
+
+// Successor returns the text position of the least suffix not less than the
+// pattern, the first occurrence in lexicographical order if the pattern occurs,
+// or -1 if there is none.
+func (sa *suffixArray[T, P]) Successor(pattern []T) int {
+	rank, _ := bounds(sa.text, sa.sa, sa.lr.Load(), sa.table.Load(), pattern)
+	if rank == len(sa.sa) {
+		return -1
+	}
+	return int(sa.sa[rank])
+}
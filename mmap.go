@@ -0,0 +1,131 @@
+// Copyright (c) 2025 Nikita Kamenev
+// Licensed under the MIT License. See LICENSE file in the project root for details.
+package suffixarr
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"unsafe"
+)
+
+// MappedSuffixArray is a suffix array whose text and suffix array are read
+// directly from a file written by SuffixArray.WriteTo, without copying them to
+// the Go heap where the platform supports memory mapping.
+// It must be closed to release the mapping and must not be used afterwards.
+// Slices returned by its queries share the mapped memory and must not be used
+// after Close either.
+type MappedSuffixArray struct {
+	*SuffixArray
+	data []byte // Mapped file contents.
+}
+
+// MappedGSA is a generalized suffix array whose text, suffix array and string
+// indices are read directly from a file written by GSA.WriteTo.
+// It must be closed to release the mapping and must not be used afterwards.
+// Slices returned by its queries, such as the suffixes yielded by Suffixes,
+// share the mapped memory and must not be used after Close either.
+type MappedGSA struct {
+	*GSA
+	data []byte // Mapped file contents.
+}
+
+// Open maps a suffix array file into memory. The file contents are validated
+// in the same way as by SuffixArray.UnmarshalBinary. Query results, such as
+// those of Lookup, refer to the mapping until it is closed; copy them to keep
+// them longer.
+func Open(path string) (*MappedSuffixArray, error) {
+	data, h, payload, err := openIndex(path, kindSuffixArray)
+	if err != nil {
+		return nil, err
+	}
+	sa := new(SuffixArray)
+	if err := sa.restore(h, payload); err != nil {
+		unmapFile(data)
+		return nil, err
+	}
+	return &MappedSuffixArray{sa, data}, nil
+}
+
+// OpenGSA maps a generalized suffix array file into memory. The file contents
+// are validated in the same way as by GSA.UnmarshalBinary. Query results that
+// share memory with the array refer to the mapping until it is closed; copy
+// them to keep them longer.
+func OpenGSA(path string) (*MappedGSA, error) {
+	data, h, payload, err := openIndex(path, kindGSA)
+	if err != nil {
+		return nil, err
+	}
+	gsa := new(GSA)
+	if err := gsa.restore(h, payload); err != nil {
+		unmapFile(data)
+		return nil, err
+	}
+	return &MappedGSA{gsa, data}, nil
+}
+
+// Close releases the mapping. Slices obtained from the array become invalid,
+// and accessing them may crash the program. Closing an already closed array
+// is a no-op.
+func (m *MappedSuffixArray) Close() error {
+	data := m.data
+	m.data, m.SuffixArray = nil, nil
+	return unmapFile(data)
+}
+
+// Close releases the mapping. Slices obtained from the array become invalid,
+// and accessing them may crash the program. Closing an already closed array
+// is a no-op.
+func (m *MappedGSA) Close() error {
+	data := m.data
+	m.data, m.GSA = nil, nil
+	return unmapFile(data)
+}
+
+// openIndex maps the file at path and checks its header and checksum.
+// On success, the payload arrays refer to the mapped memory.
+func openIndex(path string, kind uint8) (data []byte, h header, payload []int32, err error) {
+	if data, err = mapFile(path); err != nil {
+		return nil, h, nil, err
+	}
+	defer func() {
+		if err != nil {
+			unmapFile(data)
+			data = nil
+		}
+	}()
+	if len(data) < headerSize {
+		return data, h, nil, fmt.Errorf("%w: file too short", ErrFormat)
+	}
+	if h, err = decodeHeader(data[:headerSize], kind); err != nil {
+		return data, h, nil, err
+	}
+	body := data[headerSize:]
+	if uint64(len(body)) != h.length*uint64(h.arrays())*4 {
+		return data, h, nil, fmt.Errorf("%w: payload size mismatch", ErrFormat)
+	}
+	if crc32.Checksum(body, castagnoli) != h.crc {
+		return data, h, nil, fmt.Errorf("%w: payload", ErrChecksum)
+	}
+	return data, h, viewInt32(body), nil
+}
+
+// nativeLittleEndian reports whether the host stores integers in little-endian order.
+var nativeLittleEndian = binary.NativeEndian.Uint16([]byte{1, 0}) == 1
+
+// viewInt32 reinterprets little-endian encoded data as int32 values without
+// copying. On big-endian hosts the values are decoded into a new slice instead.
+func viewInt32(b []byte) []int32 {
+	n := len(b) / 4
+	if n == 0 {
+		return []int32{}
+	}
+	if nativeLittleEndian && uintptr(unsafe.Pointer(&b[0]))%4 == 0 {
+		return unsafe.Slice((*int32)(unsafe.Pointer(&b[0])), n)
+	}
+	res := make([]int32, n)
+	for i := 0; i < n; i++ {
+		res[i] = int32(binary.LittleEndian.Uint32(b[i*4:]))
+	}
+	return res
+}
@@ -0,0 +1,19 @@
+// Copyright (c) 2025 Nikita Kamenev
+// Licensed under the MIT License. See LICENSE file in the project root for details.
+
+//go:build !linux
+
+package suffixarr
+
+import "os"
+
+// mapFile reads the whole file at path into memory on platforms
+// without memory mapping support.
+func mapFile(path string) ([]byte, error) {
+	return os.ReadFile(path)
+}
+
+// unmapFile releases memory returned by mapFile.
+func unmapFile([]byte) error {
+	return nil
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2025 Nikita Kamenev
+// Licensed under the MIT License. See LICENSE file in the project root for details.
+package suffixarr
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// minParallelLen is the shortest text worth splitting between goroutines;
+// below it the cost of starting them outweighs the work they share.
+const minParallelLen = 1 << 16
+
+// Option configures suffix array construction.
+type Option func(*options)
+
+// options holds construction settings shared by all levels of SA-IS recursion.
+type options struct {
+	workers  int                             // Number of goroutines for parallel phases, 1 for a sequential build.
+	ctx      context.Context                 // Context of a cancelable build, or nil.
+	err      error                           // Context error once the build was canceled.
+	stats    *BuildStats                     // Statistics of an observed build, or nil.
+	progress func(level LevelStats, p Phase) // Called after every phase of an observed build.
+	depth    int                             // Current recursion level, -1 outside of SA-IS.
+	budget   int64                           // Memory budget in bytes, 0 if unlimited.
+	used     int64                           // Bytes reserved against the budget.
+	reuse    bool                            // Keep grown scratch buffers for later builds of a Builder.
+	data     any                             // Bucket buffer []P kept between builds, or nil.
+	rank     any                             // Dense rank buffer []P kept between builds, or nil.
+}
+
+// makeOptions applies opts to the default settings.
+func makeOptions(opts []Option) *options {
+	o := &options{workers: 1, depth: -1}
+	for _, opt := range opts {
+		opt(o)
+	}
+	// Progress reports need statistics even if the caller does not keep them.
+	if o.progress != nil && o.stats == nil {
+		o.stats = new(BuildStats)
+	}
+	return o
+}
+
+// uncheckedOptions applies opts to the default settings of a constructor
+// without an error result, which ignores the memory budget.
+func uncheckedOptions(opts []Option) *options {
+	o := makeOptions(opts)
+	o.budget = 0
+	return o
+}
+
+// contextOptions applies opts to the default settings of a build canceled by ctx.
+func contextOptions(ctx context.Context, opts []Option) *options {
+	o := makeOptions(opts)
+	o.ctx = ctx
+	return o
+}
+
+// WithParallelism builds the suffix array using up to n goroutines. Phases of SA-IS
+// that scan the text independently of the order of suffixes, such as counting
+// characters, naming LMS substrings and mapping them back to the text, are split
+// between them. Induced sorting reads the characters preceding each block of the
+// suffix array concurrently, leaving only the placement of suffixes into buckets
+// sequential. The result is identical to a sequential build. If n is not positive,
+// runtime.GOMAXPROCS(0) is used.
+func WithParallelism(n int) Option {
+	return func(o *options) {
+		if n <= 0 {
+			n = runtime.GOMAXPROCS(0)
+		}
+		o.workers = n
+	}
+}
+
+// stopped reports whether the build was canceled, recording the context error.
+// It is checked between phases and recursion levels of SA-IS, which return
+// early once it reports true, leaving an incomplete suffix array.
+func (o *options) stopped() bool {
+	if o.err == nil && o.ctx != nil {
+		o.err = o.ctx.Err()
+	}
+	return o.err != nil
+}
+
+// split returns the number of chunks to divide n items into, 1 if the items
+// should be processed sequentially.
+func (o *options) split(n int) int {
+	if o == nil || o.workers <= 1 || n < minParallelLen {
+		return 1
+	}
+	return min(o.workers, n/(minParallelLen/4))
+}
+
+// parallelFor calls fn for consecutive ranges [lo, hi) covering [0, n), one
+// range per chunk, and waits for all calls to return. A single chunk is
+// processed on the calling goroutine.
+func parallelFor(chunks, n int, fn func(chunk, lo, hi int)) {
+	if chunks <= 1 {
+		fn(0, 0, n)
+		return
+	}
+	var wg sync.WaitGroup
+	for c := 0; c < chunks; c++ {
+		wg.Add(1)
+		go func(c int) {
+			defer wg.Done()
+			fn(c, c*n/chunks, (c+1)*n/chunks)
+		}(c)
+	}
+	wg.Wait()
+}
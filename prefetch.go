@@ -0,0 +1,181 @@
+// Copyright (c) 2025 Nikita Kamenev
+// Licensed under the MIT License. See LICENSE file in the project root for details.
+package suffixarr
+
+// prefetch splits the passes of induced sorting into blocks of the suffix array
+// and, before a pass induces from a block, reads the characters preceding its
+// suffixes concurrently. These reads miss the cache and dominate the passes,
+// while placing suffixes into buckets must follow the order of the scan and
+// stays sequential, now with the characters at hand.
+type prefetch[T Symbol, P position] struct {
+	block  int // Length of the blocks, a multiple of the goroutines filling them.
+	chunks int // Number of goroutines filling a block, 1 for sequential passes.
+	pos    []P // Buffers the blocks are read into, see prefetched.
+	r, l   []T
+}
+
+// newPrefetch returns the prefetch for a suffix array of length n, which only
+// prefetches if the options split n between several goroutines.
+func newPrefetch[T Symbol, P position](n int, o *options) prefetch[T, P] {
+	chunks := o.split(n)
+	if chunks == 1 {
+		return prefetch[T, P]{chunks: 1}
+	}
+	block := chunks * minParallelLen / 4
+	return prefetch[T, P]{block: block, chunks: chunks, pos: make([]P, block), r: make([]T, block), l: make([]T, block)}
+}
+
+// fill reads the characters preceding the suffixes in slots [lo, hi) of sa.
+func (pf *prefetch[T, P]) fill(text []T, sa []P, lo, hi int) prefetched[T, P] {
+	pos, r, l := pf.pos[:hi-lo], pf.r[:hi-lo], pf.l[:hi-lo]
+	parallelFor(pf.chunks, hi-lo, func(_, a, b int) {
+		for k, j := range sa[lo+a : lo+b] {
+			j = max(j, -j)
+			pos[a+k] = j
+			if j > 0 {
+				r[a+k] = text[j-1]
+			}
+			if j > 1 {
+				l[a+k] = text[j-2]
+			}
+		}
+	})
+	return prefetched[T, P]{lo, pos, r, l}
+}
+
+// prefetched holds the characters read for a block by fill.
+type prefetched[T Symbol, P position] struct {
+	lo   int // Rank of the first slot of the block.
+	pos  []P // Suffix held by each slot when read, without its sign.
+	r, l []T // Characters one and two positions before each suffix.
+}
+
+// at returns text[j-1] and text[j-2], the latter only if j > 1, for suffix j in
+// slot i. Slots the pass filled after the block was read are looked up in text.
+func (pb *prefetched[T, P]) at(text []T, i int, j P) (r, l T) {
+	if k := i - pb.lo; uint(k) < uint(len(pb.pos)) && pb.pos[k] == j {
+		return pb.r[k], pb.l[k]
+	}
+	if j > 1 {
+		l = text[j-2]
+	}
+	return text[j-1], l
+}
+
+// induceSubL is the scan of induceSubL over prefetched blocks.
+func (pf *prefetch[T, P]) induceSubL(text []T, sa, bucket []P, minChar T) {
+	var (
+		j, k, b P
+		l, r    T
+	)
+	for lo, hi := 0, 0; lo < len(sa); lo = hi {
+		hi = min(lo+pf.block, len(sa))
+		pb := pf.fill(text, sa, lo, hi)
+		for i := lo; i < hi; i++ {
+			if sa[i] == 0 {
+				continue
+			}
+			j = sa[i]
+			if j < 0 {
+				sa[i] = -j
+				continue
+			}
+			sa[i] = 0
+			k = j - 1
+			r, l = pb.at(text, i, j)
+			if l < r {
+				k = -k
+			}
+			b = bucket[r-minChar]
+			bucket[r-minChar] = b + 1
+			sa[b] = k
+		}
+	}
+}
+
+// induceSubS is the scan of induceSubS over prefetched blocks.
+func (pf *prefetch[T, P]) induceSubS(text []T, sa, bucket []P, minChar T) {
+	var (
+		j, k, b P
+		l, r    T
+		top     = len(sa)
+	)
+	for lo, hi := len(sa), len(sa); hi > 0; hi = lo {
+		lo = max(hi-pf.block, 0)
+		pb := pf.fill(text, sa, lo, hi)
+		for i := hi - 1; i >= lo; i-- {
+			j = sa[i]
+			if j == 0 {
+				continue
+			}
+			sa[i] = 0
+			if j < 0 {
+				top--
+				sa[top] = -j
+				continue
+			}
+			k = j - 1
+			r, l = pb.at(text, i, j)
+			if l > r {
+				k = -k
+			}
+			b = bucket[r-minChar]
+			bucket[r-minChar] = b - 1
+			sa[b] = k
+		}
+	}
+}
+
+// induceL is the scan of induceL over prefetched blocks.
+func (pf *prefetch[T, P]) induceL(text []T, sa, bucket []P, minChar T) {
+	var (
+		j, k, b P
+		l, r    T
+	)
+	for lo, hi := 0, 0; lo < len(sa); lo = hi {
+		hi = min(lo+pf.block, len(sa))
+		pb := pf.fill(text, sa, lo, hi)
+		for i := lo; i < hi; i++ {
+			j = sa[i]
+			if j <= 0 {
+				continue
+			}
+			k = j - 1
+			r, l = pb.at(text, i, j)
+			if k > 0 && l < r {
+				k = -k
+			}
+			b = bucket[r-minChar]
+			bucket[r-minChar] = b + 1
+			sa[b] = k
+		}
+	}
+}
+
+// induceS is the scan of induceS over prefetched blocks.
+func (pf *prefetch[T, P]) induceS(text []T, sa, bucket []P, minChar T) {
+	var (
+		j, k, b P
+		l, r    T
+	)
+	for lo, hi := len(sa), len(sa); hi > 0; hi = lo {
+		lo = max(hi-pf.block, 0)
+		pb := pf.fill(text, sa, lo, hi)
+		for i := hi - 1; i >= lo; i-- {
+			j = sa[i]
+			if j >= 0 {
+				continue
+			}
+			j = -j
+			sa[i] = j
+			k = j - 1
+			r, l = pb.at(text, i, j)
+			if k > 0 && l <= r {
+				k = -k
+			}
+			b = bucket[r-minChar]
+			bucket[r-minChar] = b - 1
+			sa[b] = k
+		}
+	}
+}
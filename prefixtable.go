@@ -0,0 +1,145 @@
+// Copyright (c) 2025 Nikita Kamenev
+// Licensed under the MIT License. See LICENSE file in the project root for details.
+package suffixarr
+
+// maxPrefixTable is the largest number of k-symbol strings over the character
+// range of a text to be indexed by an array; larger tables are hashed.
+const maxPrefixTable = 1 << 20
+
+// prefixTable maps k-symbol strings to the interval of ranks of the suffixes
+// starting with them, so that searches begin within that interval.
+// For small character ranges, start holds for every k-symbol string, ordered by
+// its code in base sigma, the first rank of suffixes not less than it; a suffix
+// shorter than k is counted with the string it starts, padded with minChar.
+// Otherwise, hash maps the key of every k-symbol string of the text to the
+// smallest interval holding all suffixes starting with the strings of that key.
+type prefixTable[T Symbol, P position] struct {
+	k       int
+	minChar T
+	sigma   uint64          // Size of the character range of an array table.
+	start   []P             // Array table of sigma^k+1 ranks, or nil.
+	hash    map[uint64][2]P // Hash table of rank intervals [l, r), or nil.
+}
+
+// newPrefixTable builds the prefix table of k-symbol strings for a suffix array.
+func newPrefixTable[T Symbol, P position](text []T, sa []P, k int) *prefixTable[T, P] {
+	t := &prefixTable[T, P]{k: k}
+	if len(text) == 0 {
+		return t
+	}
+	minChar, maxChar := text[0], text[0]
+	for _, ch := range text {
+		minChar, maxChar = min(minChar, ch), max(maxChar, ch)
+	}
+	t.minChar, t.sigma = minChar, uint64(int64(maxChar)-int64(minChar))+1
+	if size, ok := tableSize(t.sigma, k); ok {
+		// Count suffixes by the code of their first k characters, then turn
+		// the counts into the first rank of each code.
+		t.start = make([]P, size+1)
+		for i := range text {
+			code, _ := t.code(text[i:min(i+k, len(text))])
+			t.start[code+1]++
+		}
+		for c := 1; c < len(t.start); c++ {
+			t.start[c] += t.start[c-1]
+		}
+		return t
+	}
+	// Suffixes starting with the same string are adjacent, so the interval of
+	// a key spans the suffixes of its string, or of all strings sharing the key.
+	t.hash = make(map[uint64][2]P)
+	for r, p := range sa {
+		if int(p)+k > len(text) {
+			continue
+		}
+		key := gramKey(text[p : int(p)+k])
+		w, ok := t.hash[key]
+		if !ok {
+			w = [2]P{P(r), P(r)}
+		}
+		w[1] = P(r) + 1
+		t.hash[key] = w
+	}
+	return t
+}
+
+// tableSize returns sigma^k and whether it is small enough for an array table.
+func tableSize(sigma uint64, k int) (uint64, bool) {
+	size := uint64(1)
+	for i := 0; i < k; i++ {
+		if sigma == 0 || size > maxPrefixTable/sigma {
+			return 0, false
+		}
+		size *= sigma
+	}
+	return size, true
+}
+
+// code returns the code of s padded with minChar to k characters in an array table,
+// and false if s contains a character outside the range of the text.
+func (t *prefixTable[T, P]) code(s []T) (uint64, bool) {
+	var code uint64
+	for i := 0; i < t.k; i++ {
+		code *= t.sigma
+		if i < len(s) {
+			d := uint64(int64(s[i]) - int64(t.minChar))
+			if d >= t.sigma {
+				return 0, false
+			}
+			code += d
+		}
+	}
+	return code, true
+}
+
+// gramKey hashes a k-symbol string with FNV-1a over its characters.
+func gramKey[T Symbol](s []T) uint64 {
+	key := uint64(14695981039346656037)
+	for _, ch := range s {
+		key ^= uint64(int64(ch))
+		key *= 1099511628211
+	}
+	return key
+}
+
+// window returns an interval of ranks [l, r) holding every suffix starting with
+// the prefix, and false if the table cannot narrow the search for it. An array
+// table also brackets the rank where the prefix would be inserted, l <= rank <= r;
+// a hash table only does so if some suffix starts with the prefix, since the
+// interval may belong to other strings sharing the key.
+func (t *prefixTable[T, P]) window(prefix []T) (int, int, bool) {
+	if t.start != nil {
+		// Suffixes starting with a shorter prefix span the codes of all its paddings.
+		j := min(len(prefix), t.k)
+		lo, ok := t.code(prefix[:j])
+		if !ok {
+			return 0, 0, false
+		}
+		pad := uint64(1)
+		for i := j; i < t.k; i++ {
+			pad *= t.sigma
+		}
+		return int(t.start[lo]), int(t.start[lo+pad]), true
+	}
+	if t.hash == nil || len(prefix) < t.k {
+		return 0, 0, false
+	}
+	w, ok := t.hash[gramKey(prefix[:t.k])]
+	return int(w[0]), int(w[1]), ok
+}
+
+// EnablePrefixTable precomputes the interval of suffixes starting with every string
+// of k symbols, so that lookups begin their binary search within the interval of
+// the first k symbols of the pattern instead of the whole suffix array. Texts whose
+// character range has at most 2^20 strings of k symbols get an array over all of
+// them, covering patterns shorter than k too; others get a hash table of the
+// strings occurring in the text, used for patterns of at least k symbols.
+// Narrowed searches do not use the arrays of EnableLCPSearch. A k below 1 removes
+// the table. It is safe to call concurrently with lookups.
+func (sa *suffixArray[T, P]) EnablePrefixTable(k int) {
+	if k < 1 {
+		sa.table.Store(nil)
+		return
+	}
+	sa.table.Store(newPrefixTable(sa.text, sa.sa, k))
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2025 Nikita Kamenev
+// Licensed under the MIT License. See LICENSE file in the project root for details.
+package suffixarr
+
+// Range finds the suffixes s with lo <= s < hi in lexicographical order, where a
+// suffix starting with lo compares not less than lo. An empty hi leaves the range
+// unbounded above.
+func (sa *suffixArray[T, P]) Range(lo, hi []T) []P {
+	lr, table := sa.lr.Load(), sa.table.Load()
+	l, _ := bounds(sa.text, sa.sa, lr, table, lo)
+	r := len(sa.sa)
+	if len(hi) > 0 {
+		r, _ = bounds(sa.text, sa.sa, lr, table, hi)
+	}
+	return sa.sa[l:max(l, r)]
+}
+
+// Range finds occurrences of the suffixes s of the strings with lo <= s < hi in
+// lexicographical order. An empty hi leaves the range unbounded above.
+func (gsa *generalizedSA[T, P]) Range(lo, hi []T) []Occurrence[P] {
+	return gsa.between(gsa.bound(lo), gsa.bound(hi))
+}
+
+// between is Range for bounds mapped to the characters of the text.
+func (gsa *generalizedSA[T, P]) between(lo, hi []T) []Occurrence[P] {
+	occ := []Occurrence[P]{}
+	if len(gsa.sa) == 0 {
+		return occ
+	}
+	// The separator sorts first, so a suffix ending its string compares with
+	// the bounds as the suffix of the string.
+	l, _ := bounds(gsa.text, gsa.sa, nil, nil, lo)
+	r := len(gsa.sa)
+	if len(hi) > 0 {
+		r, _ = bounds(gsa.text, gsa.sa, nil, nil, hi)
+	}
+	for _, j := range gsa.sa[l:max(l, r)] {
+		occ = gsa.appendOccurrence(occ, j)
+	}
+	return occ
+}
+
+// bound maps a Range bound to the characters of the text. Suffixes of the strings
+// compare with a character no string contains as with the character after the
+// hole, so the bound ends with that character in its place.
+func (gsa *generalizedSA[T, P]) bound(b []T) []T {
+	for i, c := range b {
+		if c == gsa.hole || c < gsa.sep {
+			res, _ := gsa.encode(b[:i])
+			return append(res[:i:i], gsa.hole+1)
+		}
+	}
+	res, _ := gsa.encode(b)
+	return res
+}
@@ -2,27 +2,96 @@
 // Licensed under the MIT License. See LICENSE file in the project root for details.
 package suffixarr
 
+import (
+	"fmt"
+	"time"
+)
+
+// Symbol is the set of character types a suffix array can be built over.
+type Symbol interface {
+	~uint8 | ~uint16 | ~int32 | ~uint32 | ~int64
+}
+
+// position is the set of integer types a suffix array stores text positions in.
+// Recursive calls of SA-IS work on summary strings of the same type, so it is
+// a subset of Symbol. int32 covers texts of up to 2^31-1 characters at half
+// the memory of int64.
+type position interface {
+	int32 | int64
+}
+
 // sais constructs a suffix array for the given text using the SA-IS algorithm.
-func sais(text []int32) []int32 {
+// It panics if the text is too long for positions of type P.
+func sais[P position, T Symbol](text []T, opts ...Option) []P {
+	sa, err := build[P](text, nil, uncheckedOptions(opts))
+	if err != nil {
+		panic(err)
+	}
+	return sa
+}
+
+// build constructs a suffix array with the given options into sa, which must be
+// zeroed and as long as the text, or nil to allocate it. It returns ErrTooLarge
+// if the text is too long for positions of type P or exceeds the memory budget,
+// and the context error if construction was canceled before completion.
+func build[P position, T Symbol](text []T, sa []P, o *options) ([]P, error) {
+	if o.stopped() {
+		return nil, o.err
+	}
+	if len(text) > maxLen[P]() {
+		return nil, fmt.Errorf("%w: text of %d characters", ErrTooLarge, len(text))
+	}
+	if o.budget > 0 {
+		if err := o.reserve(buildMemory[P](text, o.workers)); err != nil {
+			return nil, err
+		}
+	}
+	if sa == nil {
+		sa = make([]P, len(text))
+	}
 	if len(text) == 0 {
-		return []int32{} // Empty text has no suffixes.
+		return sa, nil // Empty text has no suffixes.
 	} else if len(text) == 1 {
-		return []int32{0} // Single character text has one suffix at index 0.
+		sa[0] = 0 // Single character text has one suffix at index 0.
+		return sa, nil
+	}
+	if o.observed() {
+		*o.stats = BuildStats{}
+		defer func(start time.Time) {
+			o.stats.Total = time.Since(start)
+		}(time.Now())
+	}
+	data, _ := o.data.([]P)
+	_sais[T, P](text, sa, data, 0, o)
+	if o.stopped() {
+		return nil, o.err
 	}
-	return _sais(text, nil, nil, 0)
+	return sa, nil
 }
 
 // _sais is the core recursive implementation of the SA-IS algorithm.
 // It analyzes the text to determine character range and LMS (Left-Most S-type) suffixes,
-// then delegates to induced sorting based on alphabet size. For large alphabets, it uses
-// arbitrary alphabet sorting; otherwise, it optimizes for small alphabets (<= 256).
+// then delegates to induced sorting based on alphabet size. Alphabets spanning more values
+// than the text has characters use arbitrary alphabet sorting; all others are bucketed
+// directly by character value, reusing data for small alphabets (<= 256).
 // srcAlphaSize specifies the original alphabet size for recursive calls.
-func _sais(text, sa, data []int32, srcAlphaSize int32) []int32 {
+func _sais[T Symbol, P position](text []T, sa, data []P, srcAlphaSize P, o *options) []P {
+	if o.stopped() {
+		return sa
+	}
+	o.enter(len(text))
+	defer o.leave()
+	start := o.begin()
 	var (
-		minChar, maxChar int32 = text[0], text[0]
-		l, r, numLMS     int32
+		minChar, maxChar T = text[0], text[0]
+		l, r             T
+		numLMS           P
 		S                bool
 	)
+	// Start from the last character so that it is L-type, as if followed by a
+	// sentinel smaller than any character; comparing with zero instead would
+	// misclassify it for negative symbols.
+	l = text[len(text)-1]
 	// Scan text backwards to find min/max characters and count LMS suffixes.
 	for i := len(text) - 1; i >= 0; i-- {
 		l, r = text[i], l
@@ -40,18 +109,30 @@ func _sais(text, sa, data []int32, srcAlphaSize int32) []int32 {
 			numLMS++
 		}
 	}
-	// Compute current alphabet size from character range.
-	currAlphaSize := maxChar - minChar + 1
-	if sa == nil {
-		// Allocate suffix array if not provided.
-		srcAlphaSize = currAlphaSize
-		sa = make([]int32, len(text))
+	// Compute the character range minus one; wrapping subtraction keeps
+	// the distance exact for the full 64-bit range.
+	span := uint64(int64(maxChar) - int64(minChar))
+	if lv := o.level(); lv != nil {
+		lv.LMS, lv.Names, lv.Span = int(numLMS), int(numLMS), span
+	}
+	o.end(PhaseScan, start)
+	if srcAlphaSize == 0 && span < 256 {
+		// At the top level, small alphabets size the buckets reused by all levels.
+		srcAlphaSize = P(span) + 1
 	}
-	// Switch to arbitrary alphabet sorting for large or recursive alphabets.
-	if currAlphaSize > 256 || currAlphaSize > srcAlphaSize {
-		return induceSort_arb(text, sa, data, numLMS)
+	if span < uint64(srcAlphaSize) {
+		o.path(PathSmall)
+		return induceSort(text, sa, data, minChar, numLMS, srcAlphaSize, P(span)+1, o)
 	}
-	return induceSort(text, sa, data, minChar, numLMS, srcAlphaSize, currAlphaSize)
+	// Large alphabets no longer than the text, such as names of a summary string,
+	// get one array bucket per value in the character range.
+	if span < uint64(len(text)) {
+		o.path(PathDense)
+		return induceSort(text, sa, data, minChar, numLMS, P(span)+1, P(span)+1, o)
+	}
+	// Switch to arbitrary alphabet sorting for sparse alphabets.
+	o.path(PathArbitrary)
+	return induceSort_arb(text, sa, data, numLMS, o)
 }
 
 // induceSort builds the suffix array using induced sorting for small alphabets (<= 256).
@@ -60,57 +141,86 @@ func _sais(text, sa, data []int32, srcAlphaSize int32) []int32 {
 // induction completes the suffix array.
 // Parameters include minChar (minimum character), numLMS (number of LMS suffixes),
 // srcAlphaSize (original alphabet size), and currAlphaSize (current alphabet size).
-func induceSort(text, sa, data []int32, minChar, numLMS, srcAlphaSize, currAlphaSize int32) []int32 {
+func induceSort[T Symbol, P position](text []T, sa, data []P, minChar T, numLMS, srcAlphaSize, currAlphaSize P, o *options) []P {
 	// Allocate or reuse auxiliary array for frequency and buckets.
-	if data == nil || len(data) < int(srcAlphaSize)*2 {
-		data = make([]int32, srcAlphaSize*2)
-	}
-	var summary []int32
+	data = scratch(o, &o.data, data, int(srcAlphaSize)*2)
+	var summary []P
 	freq := data[:currAlphaSize]
-	buckets := data[srcAlphaSize : srcAlphaSize+currAlphaSize]
-	frequency(text, freq, minChar)
+	buckets := data[int(srcAlphaSize) : int(srcAlphaSize)+int(currAlphaSize)]
+	start := o.begin()
+	frequency(text, freq, minChar, o)
+	if lv := o.level(); lv != nil {
+		lv.Alphabet = alphabetSize(freq)
+	}
 
 	// Insert LMS suffixes into their bucket ends.
 	insertLMS(text, sa, freq, buckets, minChar)
+	o.end(PhaseInsert, start)
+	pf := newPrefetch[T, P](len(sa), o)
 	if numLMS > 1 {
+		start = o.begin()
 		// Induce L-type suffixes for summary array.
-		induceSubL(text, sa, freq, buckets, minChar)
+		induceSubL(text, sa, freq, buckets, minChar, &pf)
+		if o.stopped() {
+			return sa
+		}
 		// Induce S-type suffixes for summary array.
-		induceSubS(text, sa, freq, buckets, minChar)
+		induceSubS(text, sa, freq, buckets, minChar, &pf)
+		o.end(PhaseSort, start)
 		// Extract LMS substring indices for summary string.
+		start = o.begin()
 		summary = sa[len(sa)-int(numLMS):]
-		maxName := summarise(text, sa, summary, numLMS)
+		maxName := summarise(text, sa, summary, numLMS, o)
+		if o.stopped() {
+			return sa
+		}
+		if lv := o.level(); lv != nil {
+			lv.Names = int(maxName)
+		}
+		o.end(PhaseName, start)
 
 		summarySA := sa[:numLMS]
 		if maxName < numLMS {
 			// Recursively build suffix array for summary string if LMS substrings repeat.
-			_sais(summary, summarySA, data, srcAlphaSize)
+			_sais(summary, summarySA, data, srcAlphaSize, o)
+			if o.stopped() {
+				return sa
+			}
+			start = o.begin()
 			// Map summary indices back to original text positions.
-			unmap(text, sa, summarySA, summary)
+			unmap(text, sa, summarySA, summary, o)
 		} else {
+			start = o.begin()
 			// Use summary directly if all LMS substrings are unique.
 			copy(summarySA, summary)
 			clear(sa[numLMS:])
 		}
 		// Expand LMS suffixes to final positions using bucket sorting.
-		expand(text, sa, summarySA, freq, buckets, minChar)
+		expand(text, sa, summarySA, freq, buckets, minChar, o)
+		o.end(PhaseMap, start)
 	}
+	start = o.begin()
 	// Final induction to complete L-type suffixes.
-	induceL(text, sa, freq, buckets, minChar)
+	induceL(text, sa, freq, buckets, minChar, &pf)
+	if o.stopped() {
+		return sa
+	}
 	// Final induction to complete S-type suffixes.
-	induceS(text, sa, freq, buckets, minChar)
+	induceS(text, sa, freq, buckets, minChar, &pf)
+	o.end(PhaseInduce, start)
 	return sa
 }
 
 // unmap maps LMS substring indices from the summary suffix array back to their original
 // positions in the text. It collects LMS positions in reverse order and reassigns them
 // based on the summary suffix array to prepare for expansion.
-func unmap(text, sa, summarySA, LMS []int32) {
+func unmap[T Symbol, P position](text []T, sa, summarySA, LMS []P, o *options) {
 	var (
-		j    int32 = int32(len(LMS))
-		l, r int32
+		j    P = P(len(LMS))
+		l, r T
 		S    bool
 	)
+	l = text[len(text)-1] // The last character is L-type.
 	// Scan text backwards to collect LMS positions.
 	for i := len(text) - 1; i >= 0; i-- {
 		l, r = text[i], l
@@ -119,12 +229,24 @@ func unmap(text, sa, summarySA, LMS []int32) {
 		} else if l > r && S {
 			S = false
 			j--
-			LMS[j] = int32(i) + 1 // Store LMS position.
+			LMS[j] = P(i) + 1 // Store LMS position.
 		}
 	}
-	// Map summary indices to original LMS positions.
-	for i := 0; i < len(LMS); i++ {
-		j = summarySA[i]
+	// Map summary indices to original LMS positions. Each index is distinct,
+	// so chunks of the summary can be mapped concurrently.
+	if chunks := o.split(len(LMS)); chunks > 1 {
+		parallelFor(chunks, len(LMS), func(_, lo, hi int) {
+			gather(sa[lo:hi], summarySA[lo:hi], LMS)
+		})
+	} else {
+		gather(sa, summarySA, LMS)
+	}
+}
+
+// gather stores the LMS positions at the summary indices of summarySA into sa,
+// clearing them from LMS.
+func gather[P position](sa, summarySA, LMS []P) {
+	for i, j := range summarySA {
 		sa[i] = LMS[j]
 		LMS[j] = 0 // Clear temporary storage.
 	}
@@ -133,10 +255,11 @@ func unmap(text, sa, summarySA, LMS []int32) {
 // expand places LMS suffixes into their final positions in the suffix array using bucket
 // sorting. It uses the summary suffix array to determine correct bucket ends for each LMS
 // suffix, preparing the array for final induction steps.
-func expand(text, sa, summarySA, freq, bucket []int32, minChar int32) {
-	frequency(text, freq, minChar)
+func expand[T Symbol, P position](text []T, sa, summarySA, freq, bucket []P, minChar T, o *options) {
+	frequency(text, freq, minChar, o)
 	bucketEnd(freq, bucket)
-	var lmsIdx, b, j int32
+	var lmsIdx, b P
+	var j T
 	// Insert LMS suffixes at bucket ends in reverse order.
 	for i := len(summarySA) - 1; i >= 0; i-- {
 		lmsIdx = summarySA[i]
@@ -149,16 +272,35 @@ func expand(text, sa, summarySA, freq, bucket []int32, minChar int32) {
 }
 
 // frequency counts occurrences of each character in the text.
-func frequency(text, freq []int32, minChar int32) {
+// Long texts over alphabets much smaller than the text are counted in chunks
+// concurrently, each into its own array, and the counts are summed.
+func frequency[T Symbol, P position](text []T, freq []P, minChar T, o *options) {
 	clear(freq)
-	for _, v := range text {
-		freq[v-minChar]++
+	chunks := o.split(len(text))
+	if chunks == 1 || len(freq)*chunks > len(text)/4 {
+		for _, v := range text {
+			freq[v-minChar]++
+		}
+		return
+	}
+	counts := make([][]P, chunks)
+	parallelFor(chunks, len(text), func(c, lo, hi int) {
+		count := make([]P, len(freq))
+		for _, v := range text[lo:hi] {
+			count[v-minChar]++
+		}
+		counts[c] = count
+	})
+	for _, count := range counts {
+		for i, n := range count {
+			freq[i] += n
+		}
 	}
 }
 
 // bucketStart calculates starting positions for L-type suffix buckets.
-func bucketStart(freq, bucket []int32) {
-	var offset int32
+func bucketStart[P position](freq, bucket []P) {
+	var offset P
 	for i, n := range freq {
 		if n > 0 {
 			bucket[i] = offset
@@ -168,8 +310,8 @@ func bucketStart(freq, bucket []int32) {
 }
 
 // bucketEnd calculates ending positions for S-type suffix buckets.
-func bucketEnd(freq, bucket []int32) {
-	var offset int32
+func bucketEnd[P position](freq, bucket []P) {
+	var offset P
 	for i, n := range freq {
 		if n > 0 {
 			offset += n
@@ -182,15 +324,17 @@ func bucketEnd(freq, bucket []int32) {
 // It scans the text backwards to identify LMS positions and places them at the end
 // of their respective character buckets, marking the last LMS position as empty if
 // multiple LMS suffixes exist.
-func insertLMS(text, sa, freq, bucket []int32, minChar int32) {
+func insertLMS[T Symbol, P position](text []T, sa, freq, bucket []P, minChar T) {
 	bucketEnd(freq, bucket)
 	var (
-		l, r, i, j, b, lastLMS int32
-		numLMS                 int
-		S                      bool
+		l, r, j       T
+		i, b, lastLMS P
+		numLMS        int
+		S             bool
 	)
+	l = text[len(text)-1] // The last character is L-type.
 	// Scan backwards to find LMS positions.
-	for i = int32(len(text) - 1); i >= 0; i-- {
+	for i = P(len(text) - 1); i >= 0; i-- {
 		l, r = text[i], l
 		if l < r {
 			S = true
@@ -214,20 +358,24 @@ func insertLMS(text, sa, freq, bucket []int32, minChar int32) {
 // induceSubL induces L-type suffixes for the summary suffix array.
 // It starts with the last character and scans forward, placing L-type suffixes
 // at the start of their character buckets and marking processed suffixes as negative.
-func induceSubL(text, sa, freq, bucket []int32, minChar int32) {
+func induceSubL[T Symbol, P position](text []T, sa, freq, bucket []P, minChar T, pf *prefetch[T, P]) {
 	bucketStart(freq, bucket)
 	var (
-		k, j     int32 = int32(len(text) - 1), 0
-		l, r     int32 = text[k-1], text[k]
-		lastChar int32 = text[len(text)-1]
-		b        int32 = bucket[lastChar-minChar]
+		k, j     P = P(len(text) - 1), 0
+		l, r     T = text[k-1], text[k]
+		lastChar T = text[len(text)-1]
+		b        P = bucket[lastChar-minChar]
 	)
 	// Initialize with last character, marking L-type or S-type.
 	if l < r {
 		k = -k
 	}
 	bucket[lastChar-minChar] = b + 1
-	sa[b] = int32(k)
+	sa[b] = P(k)
+	if pf.chunks > 1 {
+		pf.induceSubL(text, sa, bucket, minChar)
+		return
+	}
 
 	// Scan forward to induce L-type suffixes.
 	for i := 0; i < len(sa); i++ {
@@ -257,12 +405,17 @@ func induceSubL(text, sa, freq, bucket []int32, minChar int32) {
 // induceSubS induces S-type suffixes for the summary suffix array.
 // It scans backward, placing S-type suffixes at the end of their character buckets
 // and moving processed suffixes to the top of the array, marking them as negative.
-func induceSubS(text, sa, freq, bucket []int32, minChar int32) {
+func induceSubS[T Symbol, P position](text []T, sa, freq, bucket []P, minChar T, pf *prefetch[T, P]) {
 	bucketEnd(freq, bucket)
 	var (
-		j, b, l, r, k int32
-		top           = len(sa)
+		j, b, k P
+		l, r    T
+		top     = len(sa)
 	)
+	if pf.chunks > 1 {
+		pf.induceSubS(text, sa, bucket, minChar)
+		return
+	}
 	// Scan backward to induce S-type suffixes.
 	for i := len(sa) - 1; i >= 0; i-- {
 		j = sa[i]
@@ -292,20 +445,24 @@ func induceSubS(text, sa, freq, bucket []int32, minChar int32) {
 // induceL induces L-type suffixes for the final suffix array.
 // It starts with the last character and scans forward, placing L-type suffixes
 // at the start of their character buckets to complete the suffix array.
-func induceL(text, sa, freq, bucket []int32, minChar int32) {
+func induceL[T Symbol, P position](text []T, sa, freq, bucket []P, minChar T, pf *prefetch[T, P]) {
 	bucketStart(freq, bucket)
 	var (
-		k, j     int32 = int32(len(text) - 1), 0
-		l, r     int32 = text[k-1], text[k]
-		lastChar int32 = text[len(text)-1]
-		b        int32 = bucket[lastChar-minChar]
+		k, j     P = P(len(text) - 1), 0
+		l, r     T = text[k-1], text[k]
+		lastChar T = text[len(text)-1]
+		b        P = bucket[lastChar-minChar]
 	)
 	// Initialize with last character, marking L-type or S-type.
 	if l < r {
 		k = -k
 	}
 	bucket[lastChar-minChar] = b + 1
-	sa[b] = int32(k)
+	sa[b] = P(k)
+	if pf.chunks > 1 {
+		pf.induceL(text, sa, bucket, minChar)
+		return
+	}
 
 	// Scan forward to induce L-type suffixes.
 	for i := 0; i < len(sa); i++ {
@@ -331,11 +488,16 @@ func induceL(text, sa, freq, bucket []int32, minChar int32) {
 // induceS induces S-type suffixes for the final suffix array.
 // It scans backward, restoring processed suffixes and placing S-type suffixes
 // at the end of their character buckets to finalize the suffix array.
-func induceS(text, sa, freq, bucket []int32, minChar int32) {
+func induceS[T Symbol, P position](text []T, sa, freq, bucket []P, minChar T, pf *prefetch[T, P]) {
 	bucketEnd(freq, bucket)
 	var (
-		j, l, r, k, b int32
+		j, k, b P
+		l, r    T
 	)
+	if pf.chunks > 1 {
+		pf.induceS(text, sa, bucket, minChar)
+		return
+	}
 	// Scan backward to induce S-type suffixes.
 	for i := len(sa) - 1; i >= 0; i-- {
 		j = sa[i]
@@ -360,12 +522,13 @@ func induceS(text, sa, freq, bucket []int32, minChar int32) {
 }
 
 // lengthLMS computes the lengths of LMS substrings and stores them temporarily in sa.
-func lengthLMS(text, sa []int32) {
+func lengthLMS[T Symbol, P position](text []T, sa []P) {
 	var (
-		l, r int32
-		prev int32 = int32(len(text)) - 1
+		l, r T
+		prev P = P(len(text)) - 1
 		S    bool
 	)
+	l = text[len(text)-1] // The last character is L-type.
 	// Scan backwards to calculate LMS substring lengths.
 	for i := len(text) - 1; i >= 0; i-- {
 		l, r = text[i], l
@@ -374,14 +537,14 @@ func lengthLMS(text, sa []int32) {
 		} else if l > r && S {
 			S = false
 			// Store length of LMS substring.
-			sa[(i+1)/2] = prev - int32(i)
-			prev = int32(i)
+			sa[(i+1)/2] = prev - P(i)
+			prev = P(i)
 		}
 	}
 }
 
 // equalLMS checks if two LMS substrings are identical.
-func equalLMS(text []int32, l, r, lLen, rLen int32) bool {
+func equalLMS[T Symbol, P position](text []T, l, r, lLen, rLen P) bool {
 	if lLen != rLen {
 		return false
 	}
@@ -400,14 +563,64 @@ func equalLMS(text []int32, l, r, lLen, rLen int32) bool {
 // It computes LMS substring lengths, compares adjacent substrings to assign names,
 // and collects names into the summary array for recursive processing. Returns the
 // maximum name assigned, indicating the number of unique LMS substrings.
-func summarise(text, sa, summary []int32, numLMS int32) int32 {
+func summarise[T Symbol, P position](text []T, sa, summary []P, numLMS P, o *options) P {
 	// Compute LMS substring lengths.
 	lengthLMS(text, sa)
+	var maxName P
+	if chunks := o.split(len(summary)); chunks > 1 {
+		maxName = nameLMSParallel(text, sa, summary, chunks)
+	} else {
+		maxName = nameLMS(text, sa, summary)
+	}
+	if maxName >= numLMS {
+		return maxName
+	}
+	// Collect names into summary array, clearing temporary storage.
+	half := sa[:len(sa)/2]
+	chunks := o.split(len(half))
+	if chunks == 1 {
+		var j int
+		for i, curr := range half {
+			if curr > 0 {
+				half[i], summary[j] = 0, curr
+				j++
+			}
+		}
+		return maxName
+	}
+	// Count names in each chunk to find where its names start in the summary.
+	start := make([]int, chunks+1)
+	parallelFor(chunks, len(half), func(c, lo, hi int) {
+		for _, curr := range half[lo:hi] {
+			if curr > 0 {
+				start[c+1]++
+			}
+		}
+	})
+	for c := 0; c < chunks; c++ {
+		start[c+1] += start[c]
+	}
+	parallelFor(chunks, len(half), func(c, lo, hi int) {
+		j := start[c]
+		for i := lo; i < hi; i++ {
+			curr := half[i]
+			if curr <= 0 {
+				continue
+			}
+			half[i], summary[j] = 0, curr
+			j++
+		}
+	})
+	return maxName
+}
+
+// nameLMS assigns names to the sorted LMS substrings at posLMS, storing each name
+// in place of the substring length, and returns the maximum name assigned.
+func nameLMS[T Symbol, P position](text []T, sa, posLMS []P) P {
 	var (
-		name, maxName int32 = 1, 1
-		posLMS              = summary
-		prev, curr    int32 = sa[posLMS[0]], 0
-		prevLen       int32 = sa[posLMS[0]/2]
+		name, maxName P = 1, 1
+		prev, curr    P = sa[posLMS[0]], 0
+		prevLen       P = sa[posLMS[0]/2]
 	)
 	// Assign initial name to first LMS substring.
 	sa[posLMS[0]/2] = name
@@ -423,18 +636,26 @@ func summarise(text, sa, summary []int32, numLMS int32) int32 {
 		prevLen = sa[curr/2]
 		sa[curr/2] = name
 	}
-	if maxName >= numLMS {
-		return maxName
-	}
-	// Collect names into summary array, clearing temporary storage.
-	var j int
-	for i := 0; i < len(sa)/2; i++ {
-		curr := sa[i]
-		if curr <= 0 {
-			continue
+	return maxName
+}
+
+// nameLMSParallel is nameLMS with the comparisons of adjacent LMS substrings split
+// into chunks compared concurrently. Names are assigned afterwards, since they
+// overwrite the substring lengths the comparisons rely on.
+func nameLMSParallel[T Symbol, P position](text []T, sa, posLMS []P, chunks int) P {
+	diff := make([]bool, len(posLMS))
+	parallelFor(chunks, len(posLMS), func(_, lo, hi int) {
+		for i := max(lo, 1); i < hi; i++ {
+			prev, curr := posLMS[i-1], posLMS[i]
+			diff[i] = !equalLMS(text, prev, curr, sa[prev/2], sa[curr/2])
+		}
+	})
+	var name P = 1
+	for i, pos := range posLMS {
+		if diff[i] {
+			name++
 		}
-		sa[i], summary[j] = 0, curr
-		j++
+		sa[pos/2] = name
 	}
-	return maxName
+	return name
 }
@@ -0,0 +1,236 @@
+// Copyright (c) 2025 Nikita Kamenev
+// Licensed under the MIT License. See LICENSE file in the project root for details.
+package suffixarr
+
+import "slices"
+
+// comparePrefix compares a suffix with a prefix lexicographically, starting after
+// their first k characters, which must be equal. It returns the result and the
+// length of their common prefix; a suffix starting with the prefix compares equal.
+func comparePrefix[T Symbol](suf, prefix []T, k int) (int, int) {
+	for k < len(suf) && k < len(prefix) && suf[k] == prefix[k] {
+		k++
+	}
+	switch {
+	case k == len(prefix):
+		return 0, k
+	case k == len(suf) || suf[k] < prefix[k]:
+		return -1, k
+	}
+	return 1, k
+}
+
+// lcpLR holds, for the midpoint of every interval the binary search over a suffix
+// array can visit, the length of the longest common prefix of its suffix with the
+// suffixes at the left and right bounds of the interval. Bounds outside the suffix
+// array share no prefix with any suffix.
+type lcpLR[P position] struct {
+	left, right []P
+}
+
+// newLCPLR computes the LCP-LR arrays from the LCP array of a suffix array.
+func newLCPLR[P position](lcp []P) *lcpLR[P] {
+	lr := &lcpLR[P]{make([]P, len(lcp)), make([]P, len(lcp))}
+	lr.fill(lcp, -1, len(lcp))
+	return lr
+}
+
+// fill computes the arrays for the midpoints within the interval (lo, hi) and
+// returns the longest common prefix of the suffixes at lo and hi.
+func (lr *lcpLR[P]) fill(lcp []P, lo, hi int) P {
+	if hi-lo == 1 {
+		if lo < 0 || hi == len(lcp) {
+			return 0
+		}
+		return lcp[hi]
+	}
+	mid := lo + (hi-lo)/2
+	l, r := lr.fill(lcp, lo, mid), lr.fill(lcp, mid, hi)
+	lr.left[mid], lr.right[mid] = l, r
+	return min(l, r)
+}
+
+// search returns the first rank in the interval (lo, hi] whose suffix is greater
+// than the prefix, or not less than it unless after is set, and the length of
+// the common prefix of that suffix and the prefix, taken as 0 at hi. The search
+// keeps the prefix lengths matched at both bounds of the interval, and starts
+// comparing at the shorter of them, which all suffixes in between share. With
+// LCP-LR arrays it starts at the longer one and skips comparisons the arrays
+// decide, taking O(m + log n) time in total; they apply only to the intervals
+// of a search over the whole suffix array.
+func search[T Symbol, P position](text []T, sa []P, lr *lcpLR[P], prefix []T, lo, hi int, after bool) (int, int) {
+	var lcpLo, lcpHi int // Prefix lengths matched by the suffixes at lo and hi.
+	// Bounds within the suffix array share part of the prefix with the suffixes
+	// between them, which a narrow interval saves comparing again at every step.
+	if lo >= 0 {
+		_, lcpLo = comparePrefix(text[sa[lo]:], prefix, 0)
+	}
+	if hi < len(sa) {
+		_, lcpHi = comparePrefix(text[sa[hi]:], prefix, 0)
+	}
+	end := hi
+	for hi-lo > 1 {
+		mid := lo + (hi-lo)/2
+		k := min(lcpLo, lcpHi)
+		if lr != nil {
+			// A suffix agreeing with a bound for longer than the bound agrees with
+			// the prefix lies on the same side; one agreeing for less lies opposite.
+			if l := int(lr.left[mid]); lcpLo > lcpHi && l != lcpLo {
+				if l > lcpLo {
+					lo = mid
+				} else {
+					hi, lcpHi = mid, l
+				}
+				continue
+			}
+			if r := int(lr.right[mid]); lcpHi > lcpLo && r != lcpHi {
+				if r > lcpHi {
+					hi = mid
+				} else {
+					lo, lcpLo = mid, r
+				}
+				continue
+			}
+			k = max(lcpLo, lcpHi)
+		}
+		cmp, k := comparePrefix(text[sa[mid]:], prefix, k)
+		if cmp > 0 || cmp == 0 && !after {
+			hi, lcpHi = mid, k
+		} else {
+			lo, lcpLo = mid, k
+		}
+	}
+	if hi == end {
+		return hi, 0
+	}
+	return hi, lcpHi
+}
+
+// bounds returns the range of ranks [l, r) of suffixes starting with the prefix;
+// if there are none, l = r is the rank where the prefix would be inserted.
+// lr holds the LCP-LR arrays of the suffix array and table its prefix table, or
+// they are nil. A search narrowed by the table does without the LCP-LR arrays.
+func bounds[T Symbol, P position](text []T, sa []P, lr *lcpLR[P], table *prefixTable[T, P], prefix []T) (int, int) {
+	return boundsWithin(text, sa, lr, table, prefix, -1, len(sa), len(sa))
+}
+
+// boundsWithin is bounds for a prefix known to be inserted at a rank in (lo, hi],
+// whose suffixes all precede the rank end. The LCP-LR arrays only apply if that
+// is the whole suffix array.
+func boundsWithin[T Symbol, P position](text []T, sa []P, lr *lcpLR[P], table *prefixTable[T, P], prefix []T, lo, hi, end int) (int, int) {
+	if len(prefix) == 0 {
+		return 0, len(sa)
+	}
+	if lo >= 0 || hi < len(sa) {
+		// The suffix at hi may start with the prefix, while search takes
+		// none of the prefix as matched at its upper bound.
+		lr, hi = nil, min(hi+1, len(sa))
+	}
+	// Narrow the search to the suffixes sharing the first symbols of the prefix.
+	narrowed := false
+	wlo, whi := lo, hi
+	if table != nil {
+		if l, r, ok := table.window(prefix); ok {
+			wlo, whi, end, narrowed = max(lo, l-1), min(hi, r), min(end, r), true
+		}
+	}
+	// Find left boundary where suffix >= prefix.
+	var l, k int
+	if narrowed {
+		l, k = search(text, sa, nil, prefix, wlo, whi, false)
+	} else {
+		l, k = search(text, sa, lr, prefix, lo, hi, false)
+	}
+	if k < len(prefix) {
+		if narrowed && table.hash != nil {
+			// The hashed window may hold other strings sharing the key instead.
+			l, _ = search(text, sa, lr, prefix, lo, hi, false)
+		}
+		return l, l // No suffix starts with the prefix.
+	}
+	// Find right boundary where suffix > prefix, past the first match.
+	if lr != nil && !narrowed && end == len(sa) {
+		r, _ := search(text, sa, lr, prefix, -1, len(sa), true)
+		return l, r
+	}
+	r, _ := search(text, sa, nil, prefix, l, end, true)
+	return l, r
+}
+
+// minPatternsPerWorker is the fewest patterns LookupMany hands to a goroutine.
+const minPatternsPerWorker = 64
+
+// Interval is a range of ranks [Start, End) in a suffix array.
+type Interval struct {
+	Start, End int
+}
+
+// Len returns the number of ranks in the interval.
+func (iv Interval) Len() int {
+	return iv.End - iv.Start
+}
+
+// LookupMany finds the suffixes starting with each of the patterns and returns
+// the interval of their ranks for every pattern, in the order of the patterns.
+// An empty interval starts at the rank where the pattern would be inserted.
+// Patterns are searched in lexicographical order, each between the ranks found
+// for the patterns around it, so many patterns take far fewer comparisons than
+// separate lookups. Up to workers goroutines search the patterns, one if workers
+// is less than 2.
+func (sa *suffixArray[T, P]) LookupMany(patterns [][]T, workers int) []Interval {
+	order := make([]int, len(patterns))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortFunc(order, func(a, b int) int {
+		return slices.Compare(patterns[a], patterns[b])
+	})
+	res := make([]Interval, len(patterns))
+	lr, table := sa.lr.Load(), sa.table.Load()
+	chunks := max(1, min(workers, len(order)/minPatternsPerWorker))
+	parallelFor(chunks, len(order), func(_, lo, hi int) {
+		lookupSorted(sa.text, sa.sa, lr, table, patterns, order[lo:hi], res, -1, len(sa.sa), nil)
+	})
+	return res
+}
+
+// lookupSorted stores in res the intervals of the patterns at indices order,
+// sorted lexicographically and known to be inserted at ranks in (lo, hi], where
+// hi is the rank of the pattern upper, or nil if hi is the end of the suffix array.
+// Each pattern is searched between the ranks of its neighbours in order,
+// halving the patterns at every step.
+func lookupSorted[T Symbol, P position](text []T, sa []P, lr *lcpLR[P], table *prefixTable[T, P], patterns [][]T, order []int, res []Interval, lo, hi int, upper []T) {
+	for len(order) > 0 {
+		m := len(order) / 2
+		i := order[m]
+		// Suffixes starting with a smaller pattern precede those of upper,
+		// unless they start with upper as well.
+		end := len(sa)
+		if upper != nil && !hasPrefix(upper, patterns[i]) {
+			end = hi
+		}
+		l, r := boundsWithin(text, sa, lr, table, patterns[i], lo, hi, end)
+		res[i] = Interval{l, r}
+		// Smaller patterns are inserted no later, larger ones no earlier.
+		lookupSorted(text, sa, lr, table, patterns, order[:m], res, lo, l, patterns[i])
+		order, lo = order[m+1:], l-1
+	}
+}
+
+// hasPrefix reports whether s starts with prefix.
+func hasPrefix[T Symbol](s, prefix []T) bool {
+	return len(s) >= len(prefix) && slices.Equal(s[:len(prefix)], prefix)
+}
+
+// EnableLCPSearch precomputes the longest common prefixes of the suffixes compared
+// by binary search, so that later lookups take O(m + log n) time for a pattern of
+// length m however repetitive the text is, at two more positions of memory per
+// suffix. Without it, lookups skip the characters matched at both bounds of the
+// search interval, which is as fast on most texts. It is safe to call concurrently
+// with lookups, and only the first call does any work.
+func (sa *suffixArray[T, P]) EnableLCPSearch() {
+	sa.lrOnce.Do(func() {
+		lcp := kasai(sa.text, sa.sa, inverse(sa.sa), 0, false)
+		sa.lr.Store(newLCPLR(lcp))
+	})
+}
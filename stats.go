@@ -0,0 +1,154 @@
+// Copyright (c) 2025 Nikita Kamenev
+// Licensed under the MIT License. See LICENSE file in the project root for details.
+package suffixarr
+
+import (
+	"fmt"
+	"time"
+)
+
+// Phase identifies a step of SA-IS construction at one recursion level.
+type Phase int
+
+const (
+	PhaseScan   Phase = iota // Classifying suffixes and finding the character range.
+	PhaseRank                // Remapping a sparse alphabet to dense ranks.
+	PhaseInsert              // Counting characters and inserting LMS suffixes into buckets.
+	PhaseSort                // Inducing the order of LMS substrings.
+	PhaseName                // Naming LMS substrings into the summary string.
+	PhaseMap                 // Mapping sorted summary suffixes back to LMS positions.
+	PhaseInduce              // Inducing the final order of all suffixes.
+	NumPhases                // Number of phases.
+)
+
+var phaseNames = [NumPhases]string{"scan", "rank", "insert", "sort", "name", "map", "induce"}
+
+// String returns the name of the phase.
+func (p Phase) String() string {
+	if p < 0 || p >= NumPhases {
+		return fmt.Sprintf("Phase(%d)", int(p))
+	}
+	return phaseNames[p]
+}
+
+// Path identifies how characters of a recursion level are bucketed.
+type Path int
+
+const (
+	PathSmall     Path = iota // Array buckets reused across levels, for up to 256 characters.
+	PathDense                 // Array buckets over the character range, no longer than the text.
+	PathArbitrary             // Array buckets over dense ranks of a sparse alphabet.
+)
+
+// String returns the name of the path.
+func (p Path) String() string {
+	switch p {
+	case PathSmall:
+		return "small"
+	case PathDense:
+		return "dense"
+	case PathArbitrary:
+		return "arbitrary"
+	}
+	return fmt.Sprintf("Path(%d)", int(p))
+}
+
+// LevelStats describes one level of SA-IS recursion: the text at depth 0,
+// and the summary string of the level above it at every other depth.
+type LevelStats struct {
+	Depth    int                      // Recursion depth, 0 for the text.
+	Len      int                      // Length of the level's text.
+	LMS      int                      // Number of LMS suffixes, the length of the next level.
+	Names    int                      // Number of distinct LMS substrings; recursion follows if below LMS.
+	Span     uint64                   // Difference between the largest and smallest character.
+	Alphabet int                      // Number of distinct characters.
+	Path     Path                     // Bucketing path chosen for the alphabet.
+	Time     [NumPhases]time.Duration // Wall time spent in each phase.
+}
+
+// BuildStats reports how a suffix array was constructed.
+type BuildStats struct {
+	Levels []LevelStats  // Recursion levels, in order of depth.
+	Total  time.Duration // Wall time of the whole construction.
+}
+
+// WithStats fills stats with the recursion levels and phase timings of the build.
+// Timing every phase adds a small constant cost per phase.
+func WithStats(stats *BuildStats) Option {
+	return func(o *options) {
+		o.stats = stats
+	}
+}
+
+// WithProgress calls fn after every phase of the build, on the building goroutine,
+// with the statistics of the current recursion level gathered so far.
+func WithProgress(fn func(level LevelStats, phase Phase)) Option {
+	return func(o *options) {
+		o.progress = fn
+	}
+}
+
+// observed reports whether the build gathers statistics.
+func (o *options) observed() bool {
+	return o.stats != nil
+}
+
+// enter starts the next recursion level over a text of length n.
+func (o *options) enter(n int) {
+	o.depth++
+	if o.observed() {
+		o.stats.Levels = append(o.stats.Levels, LevelStats{Depth: o.depth, Len: n})
+	}
+}
+
+// leave returns to the previous recursion level.
+func (o *options) leave() {
+	o.depth--
+}
+
+// level returns the statistics of the current recursion level, or nil if the build
+// is not observed. The pointer is invalidated by entering the next level.
+func (o *options) level() *LevelStats {
+	if !o.observed() {
+		return nil
+	}
+	return &o.stats.Levels[o.depth]
+}
+
+// path records the bucketing path chosen for the current recursion level.
+func (o *options) path(p Path) {
+	if lv := o.level(); lv != nil {
+		lv.Path = p
+	}
+}
+
+// begin returns the start time of a phase if the build is observed.
+func (o *options) begin() time.Time {
+	if !o.observed() {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// end records the time of a phase started at start and reports progress.
+func (o *options) end(phase Phase, start time.Time) {
+	lv := o.level()
+	if lv == nil {
+		return
+	}
+	lv.Time[phase] += time.Since(start)
+	if o.progress != nil {
+		o.progress(*lv, phase)
+	}
+}
+
+// alphabetSize counts the distinct characters in character frequencies.
+func alphabetSize[P position](freq []P) int {
+	var n int
+	for _, f := range freq {
+		if f > 0 {
+			n++
+		}
+	}
+	return n
+}
@@ -3,71 +3,143 @@
 package suffixarr
 
 import (
+	"context"
+	"fmt"
+	"math"
 	"slices"
 	"sort"
+	"sync"
+	"sync/atomic"
 	"unicode/utf8"
+	"unsafe"
 )
 
-// sep is a special character used to separate strings in the generalized suffix array.
-// It is chosen from the Unicode Private Use Area (PUA), U+E000, to avoid
-// conflicts with actual text characters.
-const sep int32 = 0xE000
+// separator returns the separator placed between the strings of a generalized suffix
+// array over int32 text, and its hole, see generalizedSA. The separator is the value
+// just below the least character, so the end of a string sorts before any character.
+// If some string contains math.MinInt32, the separator is math.MinInt32 and the hole
+// is the least value no string contains. The strNum characters of the strings can
+// occupy at most strNum of the strNum+1 values from math.MinInt32 on, so it exists.
+func separator(src [][]int32, strNum int) (sep, hole int32) {
+	least := int32(math.MaxInt32)
+	for _, s := range src {
+		for _, c := range s {
+			least = min(least, c)
+		}
+	}
+	if least > math.MinInt32 {
+		return least - 1, least - 1
+	}
+	// Mark which of the strNum+1 values from math.MinInt32 on occur in the strings.
+	seen := newBitVector(strNum + 1)
+	for _, s := range src {
+		for _, c := range s {
+			if off := uint32(c - math.MinInt32); off <= uint32(strNum) {
+				seen.set(int(off))
+			}
+		}
+	}
+	off := 0
+	for seen.get(off) {
+		off++
+	}
+	return math.MinInt32, math.MinInt32 + int32(off)
+}
+
+// suffixArray holds a text of any symbol type and its suffix array of positions of type P.
+// It implements the queries shared by the exported suffix array types.
+type suffixArray[T Symbol, P position] struct {
+	text     []T
+	sa       []P
+	rank     []P                               // Inverse suffix array, built on first use.
+	rankOnce sync.Once                         // Guards lazy construction of rank.
+	lr       atomic.Pointer[lcpLR[P]]          // LCP-LR arrays for searching, or nil.
+	lrOnce   sync.Once                         // Guards construction of lr.
+	table    atomic.Pointer[prefixTable[T, P]] // Intervals of k-symbol prefixes, or nil.
+}
 
 // SuffixArray holds a text and its suffix array.
 type SuffixArray struct {
-	text, sa []int32
+	suffixArray[int32, int32]
 }
 
 // New creates a suffix array for the given text.
-func New(text []int32) *SuffixArray {
-	return &SuffixArray{text, sais(text)}
+// It panics if the text is longer than math.MaxInt32 characters; Build reports that as an error.
+func New(text []int32, opts ...Option) *SuffixArray {
+	return &SuffixArray{suffixArray[int32, int32]{text: text, sa: sais[int32](text, opts...)}}
 }
 
-// comparePrefix compares a suffix with a prefix lexicographically.
-func comparePrefix(suf, prefix []int32) int {
-	minLen := len(suf)
-	if minLen > len(prefix) {
-		minLen = len(prefix)
+// NewContext is like New but stops construction once ctx is done, returning
+// ctx.Err() and a nil suffix array. The context is checked between phases and
+// recursion levels of SA-IS, so a build is abandoned within one linear pass.
+func NewContext(ctx context.Context, text []int32, opts ...Option) (*SuffixArray, error) {
+	sa, err := build[int32](text, nil, contextOptions(ctx, opts))
+	if err != nil {
+		return nil, err
 	}
-	for i := 0; i < minLen; i++ {
-		if suf[i] < prefix[i] {
-			return -1
-		}
-		if suf[i] > prefix[i] {
-			return 1
-		}
-	}
-	if len(suf) < len(prefix) {
-		return -1
+	return &SuffixArray{suffixArray[int32, int32]{text: text, sa: sa}}, nil
+}
+
+// Array holds a text of any symbol type and its suffix array.
+// It lets token streams and hashed values be indexed without lossy
+// conversion to int32; lookups take patterns of the same symbol type.
+type Array[T Symbol] struct {
+	suffixArray[T, int32]
+}
+
+// NewArray creates a suffix array for the given text of any symbol type.
+func NewArray[T Symbol](text []T, opts ...Option) *Array[T] {
+	return &Array[T]{suffixArray[T, int32]{text: text, sa: sais[int32](text, opts...)}}
+}
+
+// inverse builds the inverse of a suffix array, mapping each text position to its rank.
+func inverse[P position](sa []P) []P {
+	rank := make([]P, len(sa))
+	for i := 0; i < len(sa); i++ {
+		rank[sa[i]] = P(i)
 	}
-	return 0
+	return rank
+}
+
+// inverse returns the inverse suffix array, building it on the first call.
+func (sa *suffixArray[T, P]) inverse() []P {
+	sa.rankOnce.Do(func() {
+		sa.rank = inverse(sa.sa)
+	})
+	return sa.rank
+}
+
+// Len returns the number of suffixes, which equals the length of the text.
+func (sa *suffixArray[T, P]) Len() int {
+	return len(sa.sa)
+}
+
+// Rank returns the lexicographic rank of the suffix starting at text position pos.
+// The inverse suffix array is built on the first call and kept for later queries.
+// Rank panics if pos is out of range [0, Len()).
+func (sa *suffixArray[T, P]) Rank(pos int) int {
+	return int(sa.inverse()[pos])
+}
+
+// SuffixAt returns the text position of the suffix with the given lexicographic rank.
+// SuffixAt panics if rank is out of range [0, Len()).
+func (sa *suffixArray[T, P]) SuffixAt(rank int) int {
+	return int(sa.sa[rank])
 }
 
 // lookup finds suffixes starting with the given prefix.
-func lookup(text, sa, prefix []int32) []int32 {
-	if len(prefix) == 0 {
-		return sa
-	}
+func lookup[T Symbol, P position](text []T, sa []P, lr *lcpLR[P], table *prefixTable[T, P], prefix []T) []P {
 	if len(sa) == 0 {
-		return []int32{}
+		return []P{}
 	}
-	// Find left boundary where suffix >= prefix.
-	l := sort.Search(len(sa), func(i int) bool {
-		suf := text[sa[i]:]
-		return comparePrefix(suf, prefix) >= 0
-	})
-	// Find right boundary where suffix > prefix.
-	r := l + sort.Search(len(sa)-l, func(i int) bool {
-		suf := text[sa[l+i]:]
-		return comparePrefix(suf, prefix) > 0
-	})
+	l, r := bounds(text, sa, lr, table, prefix)
 	return sa[l:r]
 }
 
 // lookupTextOrder finds suffixes starting with the prefix, sorted by text position.
-func lookupTextOrder(text, sa, prefix []int32) []int32 {
-	indices := lookup(text, sa, prefix)
-	cp := make([]int32, len(indices))
+func lookupTextOrder[T Symbol, P position](text []T, sa []P, lr *lcpLR[P], table *prefixTable[T, P], prefix []T) []P {
+	indices := lookup(text, sa, lr, table, prefix)
+	cp := make([]P, len(indices))
 	copy(cp, indices)
 	// Sort indices by their position in the original text.
 	sort.Slice(cp, func(i, j int) bool {
@@ -77,19 +149,25 @@ func lookupTextOrder(text, sa, prefix []int32) []int32 {
 }
 
 // Lookup finds suffixes starting with the given prefix.
-func (sa *SuffixArray) Lookup(prefix []int32) []int32 {
-	return lookup(sa.text, sa.sa, prefix)
+func (sa *suffixArray[T, P]) Lookup(prefix []T) []P {
+	return lookup(sa.text, sa.sa, sa.lr.Load(), sa.table.Load(), prefix)
+}
+
+// Count returns the number of suffixes starting with the prefix without allocating.
+func (sa *suffixArray[T, P]) Count(prefix []T) int {
+	l, r := bounds(sa.text, sa.sa, sa.lr.Load(), sa.table.Load(), prefix)
+	return r - l
 }
 
 // LookupTextOrder finds suffixes starting with the prefix, sorted by text position.
-func (sa *SuffixArray) LookupTextOrder(prefix []int32) []int32 {
-	return lookupTextOrder(sa.text, sa.sa, prefix)
+func (sa *suffixArray[T, P]) LookupTextOrder(prefix []T) []P {
+	return lookupTextOrder(sa.text, sa.sa, sa.lr.Load(), sa.table.Load(), prefix)
 }
 
 // LookupSuffix finds the exact suffix in the text.
 // For an empty suffix, returns len(sa) as it occurs at the end of the string.
 // Otherwise, returns the starting index or -1 if not found.
-func (sa *SuffixArray) LookupSuffix(suffix []int32) int {
+func (sa *suffixArray[T, P]) LookupSuffix(suffix []T) int {
 	if len(suffix) == 0 {
 		return len(sa.sa) // Empty suffix is at the end of the string.
 	}
@@ -107,7 +185,7 @@ func (sa *SuffixArray) LookupSuffix(suffix []int32) int {
 // LookupPrefix checks if the text starts with the given prefix.
 // For an empty prefix, returns -1 as it precedes the first character.
 // Returns 0 if matched, -2 otherwise.
-func (sa *SuffixArray) LookupPrefix(prefix []int32) int {
+func (sa *suffixArray[T, P]) LookupPrefix(prefix []T) int {
 	if len(prefix) == 0 {
 		return -1 // Empty prefix is invalid, precedes first character.
 	}
@@ -120,91 +198,209 @@ func (sa *SuffixArray) LookupPrefix(prefix []int32) int {
 	return -2
 }
 
-// index stores metadata(l, i) and buffer for a substring in the generalized suffix array.
-type index struct {
-	l, i int
-	sa   []int32
+// generalizedSA holds concatenated strings of any symbol type and their suffix array.
+// It implements the queries shared by the exported generalized suffix array types.
+// Queries never modify it, so they are safe for concurrent use, and each returns results of its own.
+//
+// The separator is less than every character of the text, so the end of a string
+// sorts before any character and suffix array order is the lexicographical order
+// of the suffixes of the strings. Characters of the strings below the hole, a value
+// no string contains, are stored one higher to make room for the separator.
+type generalizedSA[T Symbol, P position] struct {
+	src                 [][]T             // Strings, as views into text unless characters are shifted.
+	text                []T               // Concatenated strings with separators.
+	sa, strIdx, offsets []P               // Suffix array, string indices, and starting position of each string.
+	sep, hole           T                 // Separator between strings and a value no string contains.
+	chain               *documentChain[P] // Previous suffixes of the same string, built on first use.
+}
+
+// documentChain links each suffix of a generalized suffix array to the previous
+// suffix in suffix array order that starts in the same string.
+type documentChain[P position] struct {
+	once sync.Once
+	prev []P // Rank of the previous suffix of the same string, -1 if none, len(sa) at separators.
 }
 
 // GSA represents a generalized suffix array for multiple strings.
 type GSA struct {
-	src              [][]int32 // Original strings.
-	text, sa, strIdx []int32   // Concatenated text, suffix array, and string indices.
-	idx              []index   // Buffer and metadata for each substring.
-	index            []Index   // Buffer for occurrence indices for lookup results.
+	generalizedSA[int32, int32]
 }
 
-// newGSA_32 builds a generalized suffix array for int32 strings.
-func newGSA_32(src [][]int32, strNum int) *GSA {
-	// Allocate buffer for text, string indices, and suffix arrays.
+// newGSA builds a generalized suffix array with positions of type P over strings
+// of symbol type S, concatenated into a text of symbol type T able to hold the separator.
+// Characters below the hole are shifted, which keeps copies of the strings.
+func newGSA[P position, T, S Symbol](src [][]S, strNum int, sep, hole T, o *options) (generalizedSA[T, P], error) {
+	// Allocate buffer for text and string indices.
 	textSz := strNum + len(src) + 1
-	buf := make([]int32, textSz*2+strNum)
-	text := buf[:textSz]
-	strIdx, idxBuf := buf[textSz:textSz*2], buf[textSz*2:]
-	idx := make([]index, len(src))
+	if textSz > maxLen[P]() {
+		return generalizedSA[T, P]{}, fmt.Errorf("%w: strings of %d characters with separators", ErrTooLarge, textSz)
+	}
+	var t T
+	var p P
+	shifted := hole > sep
+	mem := int64(textSz) * int64(unsafe.Sizeof(t)+unsafe.Sizeof(p))
+	if shifted {
+		mem += int64(strNum) * int64(unsafe.Sizeof(t))
+	}
+	if err := o.reserve(mem); err != nil {
+		return generalizedSA[T, P]{}, err
+	}
+	text := make([]T, textSz)
+	strIdx := make([]P, textSz)
+	offsets := make([]P, len(src))
+	strs := make([][]T, len(src))
+	var chars []T
+	if shifted {
+		chars = make([]T, 0, strNum)
+	}
 
-	// Initialize text with separator.
-	text[0] = sep
-	var (
-		l, r    int        // Buffer boundaries for each substring.
-		ll, pos int = 1, 1 // Left boundary and current position in text.
-	)
+	// Initialize text with separator. The index of the leading separator, which
+	// belongs to no string, records the hole.
+	text[0], strIdx[0] = sep, P(hole)
+	pos := 1 // Current position in text.
 	// Concatenate strings with separators, track indices.
 	for i := 0; i < len(src); i++ {
+		offsets[i] = P(pos)
 		for j := 0; j < len(src[i]); j++ {
-			text[pos], strIdx[pos] = src[i][j], int32(i)
+			c := T(src[i][j])
+			if c < hole {
+				c++
+			}
+			text[pos], strIdx[pos] = c, P(i)
 			pos++
 		}
-		r += len(src[i])
-		// Store string metadata.
-		curr := idx[i]
-		curr.l, curr.sa = ll, idxBuf[l:r]
-		idx[i], strIdx[pos], text[pos] = curr, int32(i), sep
+		if shifted {
+			k := len(chars)
+			for _, c := range src[i] {
+				chars = append(chars, T(c))
+			}
+			strs[i] = chars[k:len(chars):len(chars)]
+		} else {
+			strs[i] = text[offsets[i]:pos:pos]
+		}
+		strIdx[pos], text[pos] = P(i), sep
 		pos++
-		ll += len(src[i]) + 1
-		l = r
 	}
 	// Build suffix array for concatenated text.
-	sa := sais(text)
-	return &GSA{src, text, sa, strIdx, idx, make([]Index, len(src))}
+	sa, err := build[P](text, nil, o)
+	if err != nil {
+		return generalizedSA[T, P]{}, err
+	}
+	return generalizedSA[T, P]{strs, text, sa, strIdx, offsets, sep, hole, new(documentChain[P])}, nil
 }
 
-// NewGSA creates a generalized suffix array from strings.
-func NewGSA(src []string) *GSA {
-	if len(src) == 0 {
-		return nil
+// mustGSA returns the generalized suffix array built by newGSA for a constructor
+// without an error result, panicking if the strings are too long for its positions.
+func mustGSA[T Symbol, P position](gsa generalizedSA[T, P], err error) generalizedSA[T, P] {
+	if err != nil {
+		panic(err)
 	}
-	// Convert strings to int32 slices.
+	return gsa
+}
+
+// runes converts strings to int32 slices and returns their total character count.
+func runes(src []string) ([][]int32, int) {
 	src32 := make([][]int32, len(src))
 	var sz int
 	for i := 0; i < len(src); i++ {
 		sz += utf8.RuneCountInString(src[i])
 		src32[i] = []int32(src[i])
 	}
-	return newGSA_32(src32, sz)
+	return src32, sz
+}
+
+// totalLen returns the total character count of the strings.
+func totalLen[S Symbol](src [][]S) int {
+	var sz int
+	for i := 0; i < len(src); i++ {
+		sz += len(src[i])
+	}
+	return sz
+}
+
+// NewGSA creates a generalized suffix array from strings. It returns nil if there are no
+// strings; BuildGSA validates the strings and reports errors instead.
+func NewGSA(src []string, opts ...Option) *GSA {
+	if len(src) == 0 {
+		return nil
+	}
+	src32, sz := runes(src)
+	sep, hole := separator(src32, sz)
+	gsa := mustGSA(newGSA[int32](src32, sz, sep, hole, uncheckedOptions(opts)))
+	return &GSA{gsa}
+}
+
+// NewGSAContext is like NewGSA but stops construction once ctx is done,
+// returning ctx.Err() and a nil generalized suffix array.
+func NewGSAContext(ctx context.Context, src []string, opts ...Option) (*GSA, error) {
+	if len(src) == 0 {
+		return nil, nil
+	}
+	src32, sz := runes(src)
+	sep, hole := separator(src32, sz)
+	gsa, err := newGSA[int32](src32, sz, sep, hole, contextOptions(ctx, opts))
+	if err != nil {
+		return nil, err
+	}
+	return &GSA{gsa}, nil
 }
 
 // NewGSA_32 creates a generalized suffix array from int32 slices.
-func NewGSA_32(src [][]int32) *GSA {
+func NewGSA_32(src [][]int32, opts ...Option) *GSA {
 	if len(src) == 0 {
 		return nil
 	}
-	// Calculate total character count.
-	var sz int
-	for i := 0; i < len(src); i++ {
-		sz += len(src[i])
+	sz := totalLen(src)
+	sep, hole := separator(src, sz)
+	gsa := mustGSA(newGSA[int32](src, sz, sep, hole, uncheckedOptions(opts)))
+	return &GSA{gsa}
+}
+
+// NewGSAContext_32 is like NewGSA_32 but stops construction once ctx is done,
+// returning ctx.Err() and a nil generalized suffix array.
+func NewGSAContext_32(ctx context.Context, src [][]int32, opts ...Option) (*GSA, error) {
+	if len(src) == 0 {
+		return nil, nil
+	}
+	sz := totalLen(src)
+	sep, hole := separator(src, sz)
+	gsa, err := newGSA[int32](src, sz, sep, hole, contextOptions(ctx, opts))
+	if err != nil {
+		return nil, err
 	}
-	return newGSA_32(src, sz)
+	return &GSA{gsa}, nil
 }
 
-// fillIdx fill gsa.idx with indexes from sa according to substrings
-// Returns the number of strings with occurrences.
-func (gsa *GSA) fillIdx(sa []int32) (sz int) {
-	var prev int32 // Previous processed sa index
-	for i := 0; i < len(sa); i++ {
-		j := sa[i]
+// StringIndex holds a string's occurrences in a generalized suffix array
+// with positions of type P.
+type StringIndex[P position] struct {
+	String     P
+	Occurences []P
+}
+
+// Index holds a string's occurrences in the generalized suffix array.
+type Index = StringIndex[int32]
+
+// Occurrence locates a match in a generalized suffix array with positions of type P
+// by the index of its string and its offset within that string.
+type Occurrence[P position] struct {
+	String P
+	Offset P
+}
+
+// makeIndex groups text positions sorted in ascending order by string.
+// Positions are replaced in place with offsets relative to the string start,
+// so occurrences of each string share the backing array of res.
+func (gsa *generalizedSA[T, P]) makeIndex(res []P) []StringIndex[P] {
+	var (
+		index = []StringIndex[P]{}
+		k     int // Current offset in res.
+		prev  P   // Previous processed text position.
+	)
+	for i := 0; i < len(res); i++ {
+		j := res[i]
 		// Skip separator unless followed by a valid character.
-		if gsa.text[j] == sep {
+		if gsa.text[j] == gsa.sep {
 			if int(j) == len(gsa.text)-1 {
 				break
 			}
@@ -215,100 +411,219 @@ func (gsa *GSA) fillIdx(sa []int32) (sz int) {
 			continue
 		}
 		str := gsa.strIdx[j]
-		curr := gsa.idx[str]
-		// Increment unique string count on first occurrence.
-		if curr.i == 0 {
-			sz++
+		// Start a new group on the first occurrence in a string.
+		if len(index) == 0 || index[len(index)-1].String != str {
+			index = append(index, StringIndex[P]{str, res[k:k]})
 		}
 		// Store offset relative to string start.
-		curr.sa[curr.i] = j - int32(curr.l)
-		curr.i++
-		gsa.idx[str] = curr
+		res[k] = j - gsa.offsets[str]
+		k++
+		curr := &index[len(index)-1]
+		curr.Occurences = curr.Occurences[:len(curr.Occurences)+1]
 		prev = j
 	}
-	return
+	return index
 }
 
-// Index holds a string's occurrences in the generalized suffix array.
-type Index struct {
-	String     int32
-	Occurences []int32
+// occurrence returns a single occurrence at offset for every string.
+func (gsa *generalizedSA[T, P]) occurrence(offset func(i int) P) []StringIndex[P] {
+	occ := make([]P, len(gsa.src))
+	index := make([]StringIndex[P], len(gsa.src))
+	for i := 0; i < len(gsa.src); i++ {
+		occ[i] = offset(i)
+		index[i] = StringIndex[P]{P(i), occ[i : i+1 : i+1]}
+	}
+	return index
 }
 
-// makeIndex generates occurrence indices for strings.
-func (gsa *GSA) makeIndex(sa []int32, sz int) []Index {
-	index := gsa.index[:sz]
-	var (
-		k    int   // Current index in result.
-		prev int32 // Previous processed sa index.
-	)
-	for i := 0; i < len(sa); i++ {
-		j := sa[i]
-		// Skip separator unless followed by a valid character.
-		if gsa.text[j] == sep {
-			if int(j) == len(gsa.text)-1 {
-				break
-			}
-			j++
+// encode maps a pattern to the characters of the text, shifting those below the hole.
+// It reports false if the pattern contains the hole or a character below the
+// separator, which no string contains. The pattern is copied only if shifted.
+func (gsa *generalizedSA[T, P]) encode(p []T) ([]T, bool) {
+	shift := false
+	for _, c := range p {
+		if c == gsa.hole || c < gsa.sep {
+			return nil, false
 		}
-		if j == prev {
-			continue
+		shift = shift || c < gsa.hole
+	}
+	if !shift {
+		return p, true
+	}
+	res := make([]T, len(p))
+	for i, c := range p {
+		if c < gsa.hole {
+			c++
 		}
-		str := gsa.strIdx[j]
-		idx := gsa.idx[str]
-		if idx.i == 0 {
-			continue
+		res[i] = c
+	}
+	return res, true
+}
+
+// Lookup finds prefix occurrences in the generalized suffix array in suffix array order,
+// which is the lexicographical order of the suffixes of the strings starting at them,
+// so that results from generalized suffix arrays of different strings merge by their
+// suffixes. An empty prefix occurs at every position of every string.
+func (gsa *generalizedSA[T, P]) Lookup(prefix []T) []Occurrence[P] {
+	prefix, ok := gsa.encode(prefix)
+	if !ok {
+		return []Occurrence[P]{}
+	}
+	return gsa.lookup(prefix)
+}
+
+// lookup is Lookup for a pattern mapped to the characters of the text.
+func (gsa *generalizedSA[T, P]) lookup(prefix []T) []Occurrence[P] {
+	res := lookup(gsa.text, gsa.sa, nil, nil, prefix)
+	occ := make([]Occurrence[P], 0, len(res))
+	for _, j := range res {
+		occ = gsa.appendOccurrence(occ, j)
+	}
+	return occ
+}
+
+// appendOccurrence appends the occurrence at text position j, skipping separators,
+// which only the empty prefix matches.
+func (gsa *generalizedSA[T, P]) appendOccurrence(occ []Occurrence[P], j P) []Occurrence[P] {
+	if gsa.text[j] == gsa.sep {
+		return occ
+	}
+	str := gsa.strIdx[j]
+	return append(occ, Occurrence[P]{str, j - gsa.offsets[str]})
+}
+
+// Count returns the number of prefix occurrences in the generalized suffix array,
+// as returned by Lookup, without allocating.
+func (gsa *generalizedSA[T, P]) Count(prefix []T) int {
+	prefix, ok := gsa.encode(prefix)
+	if !ok {
+		return 0
+	}
+	return gsa.count(prefix)
+}
+
+// count is Count for a pattern mapped to the characters of the text.
+func (gsa *generalizedSA[T, P]) count(prefix []T) int {
+	if len(prefix) == 0 {
+		// Every position but the separators, one after each string and one leading.
+		return max(len(gsa.text)-len(gsa.src)-1, 0)
+	}
+	l, r := bounds(gsa.text, gsa.sa, nil, nil, prefix)
+	return r - l
+}
+
+// DocumentCount returns the number of strings containing the prefix without allocating.
+// The first call links the suffixes of each string, taking a pass over the suffix
+// array and one more position of memory per suffix.
+func (gsa *generalizedSA[T, P]) DocumentCount(prefix []T) int {
+	prefix, ok := gsa.encode(prefix)
+	if !ok {
+		return 0
+	}
+	return gsa.documentCount(prefix)
+}
+
+// documentCount is DocumentCount for a pattern mapped to the characters of the text.
+func (gsa *generalizedSA[T, P]) documentCount(prefix []T) int {
+	if len(gsa.sa) == 0 {
+		return 0
+	}
+	l, r := bounds(gsa.text, gsa.sa, nil, nil, prefix)
+	prev := gsa.documentChain()
+	// A string is counted at its first suffix in the range, the one whose previous
+	// suffix of the same string precedes the range.
+	var n int
+	for _, p := range prev[l:r] {
+		if p < P(l) {
+			n++
 		}
-		// Store string index and its occurrences.
-		curr := Index{str, idx.sa[:idx.i]}
-		gsa.idx[str].i = 0
-		index[k] = curr
-		k++
-		prev = j
 	}
-	return index
+	return n
+}
+
+// documentChain returns the rank of the previous suffix of the same string for
+// every suffix, building it on first use.
+func (gsa *generalizedSA[T, P]) documentChain() []P {
+	c := gsa.chain
+	c.once.Do(func() {
+		c.prev = make([]P, len(gsa.sa))
+		last := make([]P, len(gsa.src))
+		for i := range last {
+			last[i] = -1
+		}
+		for i, j := range gsa.sa {
+			// Separators belong to no string and are never counted.
+			if gsa.text[j] == gsa.sep {
+				c.prev[i] = P(len(gsa.sa))
+				continue
+			}
+			str := gsa.strIdx[j]
+			c.prev[i], last[str] = last[str], P(i)
+		}
+	})
+	return c.prev
 }
 
 // LookupTextOrder finds prefix occurrences in the generalized suffix array, sorted by text position.
-func (gsa *GSA) LookupTextOrder(prefix []int32) []Index {
-	res := lookupTextOrder(gsa.text, gsa.sa, prefix)
-	sz := gsa.fillIdx(res)
-	return gsa.makeIndex(res, sz)
+func (gsa *generalizedSA[T, P]) LookupTextOrder(prefix []T) []StringIndex[P] {
+	prefix, ok := gsa.encode(prefix)
+	if !ok {
+		return []StringIndex[P]{}
+	}
+	return gsa.lookupTextOrder(prefix)
+}
+
+// lookupTextOrder is LookupTextOrder for a pattern mapped to the characters of the text.
+func (gsa *generalizedSA[T, P]) lookupTextOrder(prefix []T) []StringIndex[P] {
+	res := lookupTextOrder(gsa.text, gsa.sa, nil, nil, prefix)
+	return gsa.makeIndex(res)
 }
 
 // LookupSuffix finds suffix occurrences in the generalized suffix array, sorted by text position.
-func (gsa *GSA) LookupSuffix(suf []int32) []Index {
+func (gsa *generalizedSA[T, P]) LookupSuffix(suf []T) []StringIndex[P] {
+	suf, ok := gsa.encode(suf)
+	if !ok {
+		return []StringIndex[P]{}
+	}
+	return gsa.lookupSuffix(suf)
+}
+
+// lookupSuffix is LookupSuffix for a pattern mapped to the characters of the text.
+func (gsa *generalizedSA[T, P]) lookupSuffix(suf []T) []StringIndex[P] {
 	if len(suf) == 0 {
 		// Returns the length of each substring as the index of the empty suffix.
-		for i := 0; i < len(gsa.src); i++ {
-			l := len(gsa.idx[i].sa)
-			gsa.idx[i].sa[0] = int32(l)
-			gsa.index[i] = Index{int32(i), gsa.idx[i].sa[:1]}
-		}
-		return gsa.index
+		return gsa.occurrence(func(i int) P {
+			return P(len(gsa.src[i]))
+		})
 	}
-	// Append separator to ensure exact suffix match.
-	suf = append(suf, sep)
-	res := lookupTextOrder(gsa.text, gsa.sa, suf)
-	sz := gsa.fillIdx(res)
-	return gsa.makeIndex(res, sz)
+	// Append separator to ensure exact suffix match, without writing
+	// into spare capacity of the caller's slice.
+	suf = append(suf[:len(suf):len(suf)], gsa.sep)
+	res := lookupTextOrder(gsa.text, gsa.sa, nil, nil, suf)
+	return gsa.makeIndex(res)
 }
 
 // LookupPrefix finds prefix occurrences in the generalized suffix array, sorted by text position.
-func (gsa *GSA) LookupPrefix(prefix []int32) []Index {
+func (gsa *generalizedSA[T, P]) LookupPrefix(prefix []T) []StringIndex[P] {
+	prefix, ok := gsa.encode(prefix)
+	if !ok {
+		return []StringIndex[P]{}
+	}
+	return gsa.lookupPrefix(prefix)
+}
+
+// lookupPrefix is LookupPrefix for a pattern mapped to the characters of the text.
+func (gsa *generalizedSA[T, P]) lookupPrefix(prefix []T) []StringIndex[P] {
 	if len(prefix) == 0 {
 		// Return -1 for each string if prefix is empty.
-		for i := 0; i < len(gsa.src); i++ {
-			gsa.idx[i].sa[0] = -1
-			gsa.index[i] = Index{int32(i), gsa.idx[i].sa[:1]}
-		}
-		return gsa.index
+		return gsa.occurrence(func(int) P {
+			return -1
+		})
 	}
 	// Prepend separator to match string start.
-	cp := make([]int32, len(prefix)+1)
-	cp[0] = sep
+	cp := make([]T, len(prefix)+1)
+	cp[0] = gsa.sep
 	copy(cp[1:], prefix)
-	res := lookupTextOrder(gsa.text, gsa.sa, cp)
-	sz := gsa.fillIdx(res)
-	return gsa.makeIndex(res, sz)
+	res := lookupTextOrder(gsa.text, gsa.sa, nil, nil, cp)
+	return gsa.makeIndex(res)
 }
@@ -0,0 +1,56 @@
+// Copyright (c) 2025 Nikita Kamenev
+// Licensed under the MIT License. See LICENSE file in the project root for details.
+package suffixarr
+
+// SuffixArray64 holds a text and its suffix array of 64-bit positions.
+// It indexes texts longer than the 2^31-1 characters addressable by SuffixArray,
+// at twice the memory per suffix; prefer SuffixArray for smaller texts.
+type SuffixArray64 struct {
+	suffixArray[int32, int64]
+}
+
+// New64 creates a suffix array with 64-bit positions for the given text.
+func New64(text []int32, opts ...Option) *SuffixArray64 {
+	return &SuffixArray64{suffixArray[int32, int64]{text: text, sa: sais[int64](text, opts...)}}
+}
+
+// Array64 holds a text of any symbol type and its suffix array of 64-bit positions.
+type Array64[T Symbol] struct {
+	suffixArray[T, int64]
+}
+
+// NewArray64 creates a suffix array with 64-bit positions for the given text of any symbol type.
+func NewArray64[T Symbol](text []T, opts ...Option) *Array64[T] {
+	return &Array64[T]{suffixArray[T, int64]{text: text, sa: sais[int64](text, opts...)}}
+}
+
+// Index64 holds a string's occurrences in the 64-bit generalized suffix array.
+type Index64 = StringIndex[int64]
+
+// GSA64 represents a generalized suffix array of 64-bit positions for multiple strings.
+// It is needed once the strings together with their separators exceed 2^31-1 characters.
+type GSA64 struct {
+	generalizedSA[int32, int64]
+}
+
+// NewGSA64 creates a generalized suffix array with 64-bit positions from strings.
+func NewGSA64(src []string, opts ...Option) *GSA64 {
+	if len(src) == 0 {
+		return nil
+	}
+	src32, sz := runes(src)
+	sep, hole := separator(src32, sz)
+	gsa := mustGSA(newGSA[int64](src32, sz, sep, hole, uncheckedOptions(opts)))
+	return &GSA64{gsa}
+}
+
+// NewGSA64_32 creates a generalized suffix array with 64-bit positions from int32 slices.
+func NewGSA64_32(src [][]int32, opts ...Option) *GSA64 {
+	if len(src) == 0 {
+		return nil
+	}
+	sz := totalLen(src)
+	sep, hole := separator(src, sz)
+	gsa := mustGSA(newGSA[int64](src, sz, sep, hole, uncheckedOptions(opts)))
+	return &GSA64{gsa}
+}
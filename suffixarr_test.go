@@ -1,9 +1,17 @@
 package suffixarr
 
 import (
+	"bytes"
+	"context"
+	"io"
+	"math"
 	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
 	"slices"
 	"sort"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -25,6 +33,30 @@ func genRandText_32(size int) []int32 {
 	return input
 }
 
+func genRandText_dense(size int, alphaSize int32) []int32 {
+	input := make([]int32, size)
+	for i := 0; i < size; i++ {
+		input[i] = rand.Int31n(alphaSize)
+	}
+	return input
+}
+
+// genTestTexts returns texts covering edge cases of suffix sorting, by name.
+func genTestTexts() map[string][]int32 {
+	return map[string][]int32{
+		"empty string":              {},
+		"single character":          {100},
+		"same characters":           []int32("aaaaaaaaaaaaaaaaaaaaa"),
+		"banana":                    []int32("banana"),
+		"abracadabra":               []int32("abracadabra"),
+		"long random string 8":      genRandText_8_32(1000),
+		"long random string 32":     genRandText_32(1000),
+		"repeated random string 32": slices.Repeat(genRandText_32(300), 4),
+		"dense large alphabet":      genRandText_dense(3000, 1000),
+		"negative characters":       {-5, 7, -5, 7, math.MinInt32, 0, -5, 7, math.MaxInt32, -5},
+	}
+}
+
 func makeSA(text []int32) []int32 {
 	sa := make([]int32, len(text))
 	for i := range len(text) {
@@ -85,15 +117,1019 @@ func TestSAIS(t *testing.T) {
 		"long random string 32": {
 			input: genRandText_32(1000),
 		},
+		"repeated random string 32": {
+			input: slices.Repeat(genRandText_32(300), 4),
+		},
+		"dense large alphabet": {
+			input: genRandText_dense(3000, 1000),
+		},
+		"negative characters": {
+			input: []int32{-5, 7, -5, 7, math.MinInt32, 0, -5, 7, math.MaxInt32, -5},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, makeSA(tc.input), sais[int32](tc.input))
+		})
+	}
+}
+
+func makeLCP(text, sa []int32, stop func(int32) bool) []int32 {
+	lcp := make([]int32, len(sa))
+	for i := 1; i < len(sa); i++ {
+		a, b := text[sa[i-1]:], text[sa[i]:]
+		var h int
+		for h < len(a) && h < len(b) && a[h] == b[h] && !stop(a[h]) {
+			h++
+		}
+		lcp[i] = int32(h)
+	}
+	return lcp
+}
+
+func TestLCP(t *testing.T) {
+	never := func(int32) bool { return false }
+	for name, input := range genTestTexts() {
+		t.Run(name, func(t *testing.T) {
+			sa := New(input)
+			assert.Equal(t, makeLCP(sa.text, sa.sa, never), sa.LCP())
+		})
+	}
+	t.Run("banana values", func(t *testing.T) {
+		assert.Equal(t, []int32{0, 1, 3, 0, 0, 2}, New([]int32("banana")).LCP())
+	})
+}
+
+// makeGSALCP computes the LCP array of a generalized suffix array naively,
+// without the rows of separators.
+func makeGSALCP(gsa *GSA, stop func(int32) bool) []int32 {
+	full := makeLCP(gsa.text, gsa.sa, stop)
+	lcp := []int32{}
+	for r, j := range gsa.sa {
+		if gsa.text[j] != gsa.sep {
+			lcp = append(lcp, full[r])
+		}
+	}
+	return lcp
+}
+
+func TestGSALCP(t *testing.T) {
+	gsa := NewGSA([]string{"abab", "abab", "bab", "aaaa", "ab"})
+	isSep := func(c int32) bool { return c == gsa.sep }
+	lcp := gsa.LCP()
+	assert.Equal(t, makeGSALCP(gsa, isSep), lcp)
+	assert.Len(t, lcp, gsa.Count(nil))
+	// Each value is the common prefix of the suffixes it sits between.
+	var prev []int32
+	i := 0
+	for _, suf := range gsa.Suffixes() {
+		k := 0
+		for k < len(prev) && k < len(suf) && prev[k] == suf[k] {
+			k++
+		}
+		assert.Equal(t, int32(k), lcp[i], "%d: %q %q", i, string(prev), string(suf))
+		prev = suf
+		i++
+	}
+	// Identical strings share their whole length but not the separator.
+	var maxLCP int32
+	for _, v := range lcp {
+		maxLCP = max(maxLCP, v)
+	}
+	assert.Equal(t, int32(4), maxLCP)
+}
+
+func TestRank(t *testing.T) {
+	for name, input := range genTestTexts() {
+		t.Run(name, func(t *testing.T) {
+			sa := New(input)
+			assert.Equal(t, len(input), sa.Len())
+			for pos := 0; pos < sa.Len(); pos++ {
+				assert.Equal(t, pos, sa.SuffixAt(sa.Rank(pos)))
+			}
+			for rank := 0; rank < sa.Len(); rank++ {
+				assert.Equal(t, rank, sa.Rank(sa.SuffixAt(rank)))
+			}
+		})
+	}
+	t.Run("banana ranks", func(t *testing.T) {
+		sa := New([]int32("banana"))
+		ranks := make([]int, sa.Len())
+		for pos := range ranks {
+			ranks[pos] = sa.Rank(pos)
+		}
+		assert.Equal(t, []int{3, 2, 5, 1, 4, 0}, ranks)
+	})
+}
+
+func TestBWT(t *testing.T) {
+	for name, input := range genTestTexts() {
+		t.Run(name, func(t *testing.T) {
+			bwt, primary := BWT(input)
+			assert.Len(t, bwt, len(input))
+			assert.Equal(t, input, InverseBWT(bwt, primary))
+		})
+	}
+	t.Run("banana transform", func(t *testing.T) {
+		bwt, primary := BWT([]int32("banana"))
+		assert.Equal(t, []int32("annbaa"), bwt)
+		assert.Equal(t, 4, primary)
+	})
+	t.Run("invalid primary", func(t *testing.T) {
+		assert.Panics(t, func() { InverseBWT([]int32("annbaa"), 0) })
+		assert.Panics(t, func() { InverseBWT([]int32("annbaa"), 7) })
+	})
+}
+
+func TestFMIndex(t *testing.T) {
+	tests := map[string]struct {
+		input    []int32
+		patterns [][]int32
+	}{
+		"empty string": {
+			input:    []int32{},
+			patterns: [][]int32{{}, []int32("a")},
+		},
+		"single character": {
+			input:    []int32{100},
+			patterns: [][]int32{{}, {100}, {100, 100}, {7}},
+		},
+		"same characters": {
+			input:    []int32("aaaaaaaaaaaaaaaaaaaaa"),
+			patterns: [][]int32{[]int32("a"), []int32("aaaa"), []int32("b")},
+		},
+		"banana": {
+			input:    []int32("banana"),
+			patterns: [][]int32{[]int32("a"), []int32("ana"), []int32("nab"), []int32("banana")},
+		},
+		"long random string 8": {
+			input: genRandText_8_32(2000),
+		},
+		"long random string 32": {
+			input: genRandText_32(2000),
+		},
+	}
+	for name, tc := range tests {
+		// Draw additional patterns from the text itself.
+		for i := 0; i+3 <= len(tc.input); i += 97 {
+			tc.patterns = append(tc.patterns, tc.input[i:i+1], tc.input[i:i+3])
+		}
+		sa := New(tc.input)
+		for _, rate := range []int{0, 1, 3, 64} {
+			t.Run(name, func(t *testing.T) {
+				fm := NewFMIndex(tc.input, rate)
+				assert.Equal(t, len(tc.input), fm.Len())
+				for _, p := range tc.patterns {
+					exp := sa.Lookup(p)
+					assert.Equal(t, len(exp), fm.Count(p))
+					assert.Equal(t, exp, fm.Locate(p))
+				}
+				assert.Equal(t, tc.input, fm.Extract(0, len(tc.input)))
+				for i := 0; i < len(tc.input); i += 13 {
+					end := min(i+7, len(tc.input))
+					assert.Equal(t, tc.input[i:end], fm.Extract(i, end))
+				}
+			})
+		}
+	}
+	t.Run("invalid range", func(t *testing.T) {
+		fm := NewFMIndex([]int32("banana"), 2)
+		assert.Panics(t, func() { fm.Extract(4, 2) })
+		assert.Panics(t, func() { fm.Extract(0, 7) })
+	})
+}
+
+func TestMarshal(t *testing.T) {
+	tests := map[string]struct {
+		input []int32
+	}{
+		"empty string": {
+			input: []int32{},
+		},
+		"banana": {
+			input: []int32("banana"),
+		},
+		"long random string 32": {
+			input: genRandText_32(100000),
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			sa := New(tc.input)
+			data, err := sa.MarshalBinary()
+			assert.NoError(t, err)
+			var got SuffixArray
+			assert.NoError(t, got.UnmarshalBinary(data))
+			assert.Equal(t, sa.text, got.text)
+			assert.Equal(t, sa.sa, got.sa)
+
+			var buf bytes.Buffer
+			n, err := sa.WriteTo(&buf)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(len(data)), n)
+			var read SuffixArray
+			n, err = read.ReadFrom(&buf)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(len(data)), n)
+			assert.Equal(t, sa.sa, read.sa)
+		})
+	}
+	t.Run("gsa", func(t *testing.T) {
+		gsa := NewGSA([]string{"abzababab", "", "babaxyzab", "abababababababab"})
+		data, err := gsa.MarshalBinary()
+		assert.NoError(t, err)
+		var got GSA
+		assert.NoError(t, got.UnmarshalBinary(data))
+		assert.Equal(t, gsa.src, got.src)
+		for _, p := range [][]int32{[]int32("ab"), []int32("aba"), []int32("xyz")} {
+			assert.Equal(t, gsa.LookupTextOrder(p), got.LookupTextOrder(p))
+			assert.Equal(t, gsa.LookupPrefix(p), got.LookupPrefix(p))
+			assert.Equal(t, gsa.LookupSuffix(p), got.LookupSuffix(p))
+		}
+		var buf bytes.Buffer
+		_, err = gsa.WriteTo(&buf)
+		assert.NoError(t, err)
+		var read GSA
+		_, err = read.ReadFrom(&buf)
+		assert.NoError(t, err)
+		assert.Equal(t, gsa.sa, read.sa)
+		// A generalized suffix array cannot be decoded as a plain one.
+		assert.ErrorIs(t, new(SuffixArray).UnmarshalBinary(data), ErrFormat)
+
+		// The hole recorded at the leading separator may not lie below it.
+		forged := GSA{gsa.generalizedSA}
+		forged.strIdx = slices.Clone(gsa.strIdx)
+		forged.strIdx[0] = gsa.sep - 1
+		data, err = forged.MarshalBinary()
+		assert.NoError(t, err)
+		assert.ErrorIs(t, new(GSA).UnmarshalBinary(data), ErrFormat)
+	})
+}
+
+func TestUnmarshalInvalid(t *testing.T) {
+	data, err := New([]int32("banana")).MarshalBinary()
+	assert.NoError(t, err)
+	corrupt := func(fn func(b []byte) []byte) []byte {
+		return fn(slices.Clone(data))
+	}
+	tests := map[string]struct {
+		data []byte
+		err  error
+	}{
+		"empty": {
+			data: nil,
+			err:  ErrFormat,
+		},
+		"bad magic": {
+			data: corrupt(func(b []byte) []byte { b[0] = 'X'; return b }),
+			err:  ErrFormat,
+		},
+		"header checksum": {
+			data: corrupt(func(b []byte) []byte { b[8]++; return b }),
+			err:  ErrChecksum,
+		},
+		"payload checksum": {
+			data: corrupt(func(b []byte) []byte { b[headerSize]++; return b }),
+			err:  ErrChecksum,
+		},
+		"truncated": {
+			data: data[:len(data)-3],
+			err:  ErrFormat,
+		},
+		"trailing bytes": {
+			data: append(slices.Clone(data), 0),
+			err:  ErrFormat,
+		},
+		"future version": {
+			data: corrupt(func(b []byte) []byte {
+				h := header{version: formatVersion + 1, kind: kindSuffixArray, width: 4, length: 6}
+				copy(b, h.encode())
+				return b
+			}),
+			err: ErrFormat,
+		},
+		"not a permutation": {
+			data: func() []byte {
+				b, _ := (&SuffixArray{suffixArray[int32, int32]{text: []int32("ab"), sa: []int32{1, 1}}}).MarshalBinary()
+				return b
+			}(),
+			err: ErrFormat,
+		},
+		"not sorted": {
+			data: func() []byte {
+				b, _ := (&SuffixArray{suffixArray[int32, int32]{text: []int32("ab"), sa: []int32{1, 0}}}).MarshalBinary()
+				return b
+			}(),
+			err: ErrFormat,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var sa SuffixArray
+			assert.ErrorIs(t, sa.UnmarshalBinary(tc.data), tc.err)
+		})
+	}
+
+	// A forged header declaring a huge payload is rejected without allocating it.
+	forged := func(kind uint8) []byte {
+		h := header{version: formatVersion, kind: kind, width: 4, length: 1 << 28, strNum: 1}
+		return h.encode()
 	}
+	allocated := func(fn func() error) uint64 {
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		assert.ErrorIs(t, fn(), ErrFormat)
+		runtime.ReadMemStats(&after)
+		return after.TotalAlloc - before.TotalAlloc
+	}
+	var sa SuffixArray
+	var gsa GSA
+	for name, fn := range map[string]func() error{
+		"unmarshal":     func() error { return sa.UnmarshalBinary(forged(kindSuffixArray)) },
+		"read":          func() error { _, err := sa.ReadFrom(bytes.NewReader(forged(kindSuffixArray))); return err },
+		"unmarshal gsa": func() error { return gsa.UnmarshalBinary(forged(kindGSA)) },
+		"read gsa":      func() error { _, err := gsa.ReadFrom(bytes.NewReader(forged(kindGSA))); return err },
+	} {
+		assert.Less(t, allocated(fn), uint64(1<<20), name)
+	}
+}
+
+func writeFile(t *testing.T, wt interface {
+	WriteTo(w io.Writer) (int64, error)
+}) string {
+	path := filepath.Join(t.TempDir(), "index")
+	f, err := os.Create(path)
+	assert.NoError(t, err)
+	_, err = wt.WriteTo(f)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+	return path
+}
+
+func TestOpen(t *testing.T) {
+	text := genRandText_8_32(10000)
+	sa := New(text)
+	m, err := Open(writeFile(t, sa))
+	assert.NoError(t, err)
+	for i := 0; i+4 <= len(text); i += 101 {
+		p := text[i : i+4]
+		assert.Equal(t, sa.Lookup(p), m.Lookup(p))
+		assert.Equal(t, sa.LookupTextOrder(p), m.LookupTextOrder(p))
+	}
+	assert.NoError(t, m.Close())
+	assert.NoError(t, m.Close())
+
+	t.Run("empty", func(t *testing.T) {
+		m, err := Open(writeFile(t, New([]int32{})))
+		assert.NoError(t, err)
+		assert.Equal(t, []int32{}, m.Lookup([]int32("a")))
+		assert.NoError(t, m.Close())
+	})
+	t.Run("corrupt", func(t *testing.T) {
+		path := writeFile(t, sa)
+		data, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		data[len(data)-1]++
+		assert.NoError(t, os.WriteFile(path, data, 0o644))
+		_, err = Open(path)
+		assert.ErrorIs(t, err, ErrChecksum)
+		assert.NoError(t, os.WriteFile(path, data[:headerSize-1], 0o644))
+		_, err = Open(path)
+		assert.ErrorIs(t, err, ErrFormat)
+		_, err = OpenGSA(writeFile(t, sa))
+		assert.ErrorIs(t, err, ErrFormat)
+	})
+	t.Run("missing", func(t *testing.T) {
+		_, err := Open(filepath.Join(t.TempDir(), "missing"))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
 
+func TestOpenGSA(t *testing.T) {
+	src := []string{"abzababab", "", "babaxyzab", "abababababababab", "xqabqqqhfimmoabmhbaabfiq"}
+	gsa := NewGSA(src)
+	m, err := OpenGSA(writeFile(t, gsa))
+	assert.NoError(t, err)
+	for _, p := range [][]int32{[]int32("ab"), []int32("aba"), []int32("xyz"), {}} {
+		assert.Equal(t, gsa.LookupTextOrder(p), m.LookupTextOrder(p))
+		assert.Equal(t, gsa.LookupPrefix(p), m.LookupPrefix(p))
+		assert.Equal(t, gsa.LookupSuffix(p), m.LookupSuffix(p))
+	}
+	assert.NoError(t, m.Close())
+}
+
+func TestNewBytes(t *testing.T) {
+	tests := map[string]struct {
+		input []byte
+	}{
+		"empty string": {
+			input: []byte{},
+		},
+		"single character": {
+			input: []byte{100},
+		},
+		"banana": {
+			input: []byte("banana"),
+		},
+		"min/max edges": {
+			input: []byte{0, 255, 0, 255, 255},
+		},
+		"long random string 8": {
+			input: func() []byte {
+				b := make([]byte, 10000)
+				rand.Read(b)
+				return b
+			}(),
+		},
+	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			assert.Equal(t, makeSA(tc.input), sais(tc.input))
+			text := make([]int32, len(tc.input))
+			for i, c := range tc.input {
+				text[i] = int32(c)
+			}
+			exp := New(text)
+			sa := NewBytes(tc.input)
+			assert.Equal(t, exp.sa, sa.sa)
+			assert.Equal(t, exp.LCP(), sa.LCP())
+			for i := 0; i+3 <= len(tc.input); i += 97 {
+				assert.Equal(t, exp.Lookup(text[i:i+3]), sa.Lookup(tc.input[i:i+3]))
+				assert.Equal(t, exp.LookupTextOrder(text[i:i+2]), sa.LookupTextOrder(tc.input[i:i+2]))
+			}
+			assert.Equal(t, exp.LookupSuffix(text[len(text)/2:]), sa.LookupSuffix(tc.input[len(text)/2:]))
+			assert.Equal(t, exp.LookupPrefix(text[:len(text)/2]), sa.LookupPrefix(tc.input[:len(text)/2]))
 		})
 	}
 }
 
+func TestNewGSABytes(t *testing.T) {
+	src := []string{"abzababab", "", "babaxyzab", "abababababababab", "\xff\x00ab\xff", "\xffab"}
+	src8 := make([][]byte, len(src))
+	src32 := make([][]int32, len(src))
+	for i, s := range src {
+		src8[i] = []byte(s)
+		for _, c := range src8[i] {
+			src32[i] = append(src32[i], int32(c))
+		}
+	}
+	exp := NewGSA_32(src32)
+	gsa := NewGSABytes(src8)
+	for _, p := range []string{"ab", "aba", "xyz", "\xff", "\xffab", "b\xff", ""} {
+		p32 := make([]int32, len(p))
+		for i := 0; i < len(p); i++ {
+			p32[i] = int32(p[i])
+		}
+		assert.Equal(t, exp.LookupTextOrder(p32), gsa.LookupTextOrder([]byte(p)), p)
+		assert.Equal(t, exp.LookupPrefix(p32), gsa.LookupPrefix([]byte(p)), p)
+		assert.Equal(t, exp.LookupSuffix(p32), gsa.LookupSuffix([]byte(p)), p)
+	}
+	assert.Equal(t, exp.LCP(), gsa.LCP())
+	assert.Nil(t, NewGSABytes(nil))
+}
+
+func makeSAOf[T Symbol](text []T) []int32 {
+	sa := make([]int32, len(text))
+	for i := range len(text) {
+		sa[i] = int32(i)
+	}
+	sort.Slice(sa, func(i int, j int) bool {
+		return slices.Compare(text[sa[i]:], text[sa[j]:]) < 0
+	})
+	return sa
+}
+
+func testArray[T Symbol](t *testing.T, text []T) {
+	sa := NewArray(text)
+	assert.Equal(t, makeSAOf(text), sa.sa)
+	for i := 0; i+2 <= len(text); i += 37 {
+		exp := slices.Clone(sa.LookupTextOrder(text[i : i+2]))
+		// Positions in text order must all start with the pattern.
+		for _, j := range exp {
+			assert.Equal(t, text[i:i+2], text[j:j+2])
+		}
+		assert.Contains(t, exp, int32(i))
+	}
+}
+
+func TestNewArray(t *testing.T) {
+	t.Run("uint16 tokens", func(t *testing.T) {
+		text := make([]uint16, 2000)
+		for i := range text {
+			text[i] = uint16(rand.Intn(50000))
+		}
+		testArray(t, text)
+	})
+	t.Run("uint16 small alphabet", func(t *testing.T) {
+		text := make([]uint16, 2000)
+		for i := range text {
+			text[i] = 60000 + uint16(rand.Intn(4))
+		}
+		testArray(t, text)
+	})
+	t.Run("uint32", func(t *testing.T) {
+		text := make([]uint32, 2000)
+		for i := range text {
+			text[i] = rand.Uint32()
+		}
+		text[0], text[1] = 0, math.MaxUint32
+		testArray(t, text)
+	})
+	t.Run("int32 full range", func(t *testing.T) {
+		text := genRandText_32(2000)
+		text[0], text[1], text[2] = math.MinInt32, math.MaxInt32, -1
+		testArray(t, text)
+		assert.Equal(t, makeSA(text), New(text).sa)
+	})
+	t.Run("int64 hashes", func(t *testing.T) {
+		text := make([]int64, 2000)
+		for i := range text {
+			text[i] = int64(rand.Uint64())
+		}
+		text[0], text[1] = math.MinInt64, math.MaxInt64
+		copy(text[1000:], text[:500]) // Repeats force recursion.
+		testArray(t, text)
+	})
+	t.Run("int64 narrow range", func(t *testing.T) {
+		text := make([]int64, 2000)
+		for i := range text {
+			text[i] = math.MaxInt64 - int64(rand.Intn(3))
+		}
+		testArray(t, text)
+	})
+	t.Run("negative last symbol", func(t *testing.T) {
+		testArray(t, []int64{5, -3, 5, -3, 2, 5, -3, 5, -3, -7})
+		text := []int32{-1, 4, -1, 4, 0, -1, 4, -1, 4, -2}
+		assert.Equal(t, makeSA(text), New(text).sa)
+	})
+	t.Run("named type", func(t *testing.T) {
+		type token uint16
+		testArray(t, []token{3, 1, 3, 1, 3, 1, 2, 2, 0})
+	})
+}
+
+func widen64(a []int32) []int64 {
+	res := make([]int64, len(a))
+	for i, v := range a {
+		res[i] = int64(v)
+	}
+	return res
+}
+
+func TestNew64(t *testing.T) {
+	t.Run("small alphabet", func(t *testing.T) {
+		text := genRandText_8_32(3000)
+		copy(text[2000:], text[:800]) // Repeats force recursion.
+		sa, sa64 := New(text), New64(text)
+		assert.Equal(t, widen64(sa.sa), sa64.sa)
+		assert.Equal(t, widen64(sa.LCP()), sa64.LCP())
+		for i := 0; i+3 <= len(text); i += 101 {
+			p := text[i : i+3]
+			assert.Equal(t, widen64(sa.Lookup(p)), sa64.Lookup(p))
+			assert.Equal(t, widen64(sa.LookupTextOrder(p)), sa64.LookupTextOrder(p))
+		}
+		assert.Equal(t, sa.Rank(17), sa64.Rank(17))
+		assert.Equal(t, sa.SuffixAt(17), sa64.SuffixAt(17))
+	})
+	t.Run("arbitrary alphabet", func(t *testing.T) {
+		text := genRandText_32(3000)
+		copy(text[2000:], text[:800])
+		assert.Equal(t, widen64(New(text).sa), New64(text).sa)
+	})
+	t.Run("generic symbols", func(t *testing.T) {
+		text := make([]uint16, 2000)
+		for i := range text {
+			text[i] = uint16(rand.Intn(50000))
+		}
+		assert.Equal(t, widen64(makeSAOf(text)), NewArray64(text).sa)
+	})
+	t.Run("empty", func(t *testing.T) {
+		assert.Equal(t, []int64{}, New64(nil).sa)
+		assert.Equal(t, []int64{0}, New64([]int32{7}).sa)
+	})
+}
+
+func TestNewGSA64(t *testing.T) {
+	src := []string{"abzababab", "", "babaxyzab", "abababababababab"}
+	gsa, gsa64 := NewGSA(src), NewGSA64(src)
+	index64 := func(index []Index) []Index64 {
+		res := make([]Index64, len(index))
+		for i, idx := range index {
+			res[i] = Index64{int64(idx.String), widen64(idx.Occurences)}
+		}
+		return res
+	}
+	for _, p := range []string{"ab", "aba", "xyz", "b", ""} {
+		assert.Equal(t, index64(gsa.LookupTextOrder([]int32(p))), gsa64.LookupTextOrder([]int32(p)), p)
+		assert.Equal(t, index64(gsa.LookupPrefix([]int32(p))), gsa64.LookupPrefix([]int32(p)), p)
+		assert.Equal(t, index64(gsa.LookupSuffix([]int32(p))), gsa64.LookupSuffix([]int32(p)), p)
+	}
+	assert.Equal(t, widen64(gsa.LCP()), gsa64.LCP())
+	assert.Equal(t, widen64(NewGSA_32([][]int32{{3, 1, 2}, {1, 2}}).sa), NewGSA64_32([][]int32{{3, 1, 2}, {1, 2}}).sa)
+	assert.Nil(t, NewGSA64(nil))
+	assert.Nil(t, NewGSA64_32(nil))
+}
+
+func TestParallel(t *testing.T) {
+	tests := map[string][]int32{
+		"small alphabet":       genRandText_dense(400000, 4),
+		"byte alphabet":        genRandText_8_32(400000),
+		"dense large alphabet": genRandText_dense(400000, 20000),
+		"arbitrary alphabet":   genRandText_32(400000),
+		"repeated pattern":     slices.Repeat(genRandText_dense(1000, 3), 400),
+		"short":                genRandText_8_32(1000),
+	}
+	for name, text := range tests {
+		t.Run(name, func(t *testing.T) {
+			exp := New(text)
+			for _, n := range []int{0, 2, 7} {
+				assert.Equal(t, exp.sa, New(text, WithParallelism(n)).sa, n)
+			}
+		})
+	}
+	t.Run("GSA", func(t *testing.T) {
+		src := make([][]int32, 100)
+		for i := range src {
+			src[i] = genRandText_dense(3000, 5)
+		}
+		assert.Equal(t, NewGSA_32(src).sa, NewGSA_32(src, WithParallelism(4)).sa)
+	})
+	t.Run("bytes", func(t *testing.T) {
+		text := make([]byte, 300000)
+		for i := range text {
+			text[i] = byte(rand.Intn(3))
+		}
+		assert.Equal(t, NewBytes(text).sa, NewBytes(text, WithParallelism(3)).sa)
+	})
+}
+
+// countdownCtx is a context canceled after its Err method was called n times.
+type countdownCtx struct {
+	context.Context
+	n int
+}
+
+func (c *countdownCtx) Err() error {
+	if c.n == 0 {
+		return context.Canceled
+	}
+	c.n--
+	return nil
+}
+
+func TestNewContext(t *testing.T) {
+	text := slices.Repeat(genRandText_32(500), 6) // Repeats force recursion.
+	exp := New(text)
+
+	sa, err := NewContext(context.Background(), text)
+	assert.NoError(t, err)
+	assert.Equal(t, exp.sa, sa.sa)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	sa, err = NewContext(ctx, text)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, sa)
+
+	// Cancel at every check in turn, until the build gets through all of them.
+	var n int
+	for ; ; n++ {
+		sa, err = NewContext(&countdownCtx{context.Background(), n}, text)
+		if err == nil {
+			break
+		}
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Nil(t, sa)
+	}
+	assert.Greater(t, n, 5)
+	assert.Equal(t, exp.sa, sa.sa)
+}
+
+func TestNewGSAContext(t *testing.T) {
+	src := []string{"abzababab", "", "babaxyzab", "abababababababab"}
+	gsa, err := NewGSAContext(context.Background(), src)
+	assert.NoError(t, err)
+	assert.Equal(t, NewGSA(src).sa, gsa.sa)
+
+	src32 := [][]int32{[]int32("abab"), []int32("bab")}
+	gsa, err = NewGSAContext_32(context.Background(), src32)
+	assert.NoError(t, err)
+	assert.Equal(t, NewGSA_32(src32).sa, gsa.sa)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	gsa, err = NewGSAContext(ctx, src)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, gsa)
+	gsa, err = NewGSAContext_32(ctx, src32)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, gsa)
+
+	gsa, err = NewGSAContext(ctx, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, gsa)
+}
+
+func countLMS(text []int32) int {
+	// A suffix is S-type if it is smaller than the suffix following it.
+	isS := func(i int) bool {
+		return slices.Compare(text[i:], text[i+1:]) < 0
+	}
+	var n int
+	for i := 1; i < len(text); i++ {
+		// Position i is LMS if its suffix is S-type and the previous one is L-type.
+		if isS(i) && !isS(i-1) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestBuildStats(t *testing.T) {
+	text := slices.Repeat(genRandText_32(500), 8) // Repeats force recursion.
+	text = append(text, math.MinInt32)
+	var (
+		stats  BuildStats
+		phases []Phase
+		depths []int
+	)
+	sa := New(text, WithStats(&stats), WithProgress(func(level LevelStats, phase Phase) {
+		phases = append(phases, phase)
+		depths = append(depths, level.Depth)
+	}))
+	assert.Equal(t, makeSA(text), sa.sa)
+	assert.Greater(t, len(stats.Levels), 1)
+	assert.Positive(t, stats.Total)
+
+	top := stats.Levels[0]
+	assert.Equal(t, 0, top.Depth)
+	assert.Equal(t, len(text), top.Len)
+	assert.Equal(t, countLMS(text), top.LMS)
+	assert.Equal(t, 501, top.Alphabet)
+	assert.Equal(t, PathArbitrary, top.Path)
+	assert.Positive(t, top.Time[PhaseRank])
+	for i, lv := range stats.Levels {
+		assert.Equal(t, i, lv.Depth)
+		assert.LessOrEqual(t, lv.Names, lv.LMS)
+		if i > 0 {
+			prev := stats.Levels[i-1]
+			assert.Equal(t, prev.LMS, lv.Len)
+			assert.Equal(t, prev.Names, lv.Alphabet)
+			assert.Less(t, prev.Names, prev.LMS)
+			assert.NotEqual(t, PathArbitrary, lv.Path)
+		}
+	}
+	last := stats.Levels[len(stats.Levels)-1]
+	assert.True(t, last.Names == last.LMS || last.LMS <= 1)
+
+	// Progress starts with the scan of the text and ends with its final induction.
+	assert.Equal(t, PhaseScan, phases[0])
+	assert.Equal(t, PhaseInduce, phases[len(phases)-1])
+	assert.Equal(t, 0, depths[len(depths)-1])
+	assert.Contains(t, depths, len(stats.Levels)-1)
+
+	// Stats are reset by every build and stay empty without recursion.
+	New([]int32("ab"), WithStats(&stats))
+	assert.Len(t, stats.Levels, 1)
+	assert.Equal(t, PathSmall, stats.Levels[0].Path)
+	assert.Equal(t, "induce", PhaseInduce.String())
+	assert.Equal(t, "dense", PathDense.String())
+}
+
+func TestBuild(t *testing.T) {
+	text := genRandText_32(2000)
+	sa, err := Build(text)
+	assert.NoError(t, err)
+	assert.Equal(t, New(text).sa, sa.sa)
+
+	sa, err = Build(nil)
+	assert.ErrorIs(t, err, ErrEmpty)
+	assert.Nil(t, sa)
+
+	t.Run("memory budget", func(t *testing.T) {
+		need := buildMemory[int32](text, 1)
+		assert.Greater(t, need, int64(4*len(text)))
+		sa, err := Build(text, WithMemoryBudget(need-1))
+		assert.ErrorIs(t, err, ErrTooLarge)
+		assert.Nil(t, sa)
+		sa, err = Build(text, WithMemoryBudget(need))
+		assert.NoError(t, err)
+		assert.Equal(t, New(text).sa, sa.sa)
+		// Constructors without an error result ignore the budget.
+		assert.Equal(t, sa.sa, New(text, WithMemoryBudget(1)).sa)
+		_, err = NewContext(context.Background(), text, WithMemoryBudget(1))
+		assert.ErrorIs(t, err, ErrTooLarge)
+	})
+	t.Run("memory estimate", func(t *testing.T) {
+		small := buildMemory[int32](genRandText_8_32(2000), 1)
+		dense := buildMemory[int32](genRandText_dense(2000, 1500), 1)
+		assert.Less(t, small, dense)
+		assert.Less(t, dense, buildMemory[int32](text, 1))
+		assert.Equal(t, 2*buildMemory[int32](text, 1), buildMemory[int64](text, 1))
+	})
+	t.Run("parallel memory estimate", func(t *testing.T) {
+		// Chunks and prefetched blocks of every recursion level take extra memory.
+		text := genRandText_dense(1<<20, 4)
+		for _, workers := range []int{2, 16, 64} {
+			need := buildMemory[int32](text, workers)
+			assert.Greater(t, need, buildMemory[int32](text, 1))
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			_, err := Build(text, WithParallelism(workers), WithMemoryBudget(need))
+			runtime.ReadMemStats(&after)
+			assert.NoError(t, err)
+			assert.LessOrEqual(t, after.TotalAlloc-before.TotalAlloc, uint64(need), workers)
+		}
+	})
+	assert.Equal(t, math.MaxInt32, maxLen[int32]())
+	assert.Equal(t, math.MaxInt, maxLen[int64]())
+}
+
+func TestBuilder(t *testing.T) {
+	texts := map[string][]int32{
+		"empty":        {},
+		"single":       {7},
+		"repeated":     slices.Repeat([]int32{1, 2}, 500),
+		"random 8":     genRandText_8_32(3000),
+		"dense large":  genRandText_dense(3000, 2000),
+		"random 32":    genRandText_32(3000),
+		"random 32 2x": genRandText_32(6000),
+	}
+	var b Builder
+	var dst []int32
+	for name, text := range texts {
+		t.Run(name, func(t *testing.T) {
+			dst = b.Build(text, dst)
+			assert.Equal(t, New(text).sa, dst)
+		})
+	}
+	t.Run("no allocations", func(t *testing.T) {
+		for name, text := range texts {
+			allocs := testing.AllocsPerRun(10, func() {
+				dst = b.Build(text, dst)
+			})
+			assert.Zero(t, allocs, name)
+		}
+		// Texts too short to split are built sequentially by parallel builders too.
+		pb := NewBuilder(WithParallelism(4))
+		text := texts["random 32"]
+		dst = pb.Build(text, dst)
+		assert.Zero(t, testing.AllocsPerRun(10, func() {
+			dst = pb.Build(text, dst)
+		}))
+	})
+	t.Run("reset", func(t *testing.T) {
+		b.Reset()
+		text := texts["random 32"]
+		assert.Equal(t, New(text).sa, b.Build(text, nil))
+	})
+	t.Run("pool", func(t *testing.T) {
+		pool := sync.Pool{New: func() any { return NewBuilder(WithParallelism(2)) }}
+		text := genRandText_32(minParallelLen * 2)
+		b := pool.Get().(*Builder)
+		assert.Equal(t, New(text).sa, b.Build(text, nil))
+		pool.Put(b)
+	})
+}
+
+func TestBuildGSA(t *testing.T) {
+	src := []string{"abzababab", "", "babaxyzab"}
+	gsa, err := BuildGSA(src)
+	assert.NoError(t, err)
+	assert.Equal(t, NewGSA(src).sa, gsa.sa)
+
+	_, err = BuildGSA(nil)
+	assert.ErrorIs(t, err, ErrEmpty)
+	_, err = BuildGSA_32(nil)
+	assert.ErrorIs(t, err, ErrEmpty)
+
+	// Strings may contain any character, including the least int32 value.
+	gsa, err = BuildGSA_32([][]int32{[]int32("ab"), {'a', math.MinInt32, 'b'}})
+	assert.NoError(t, err)
+	assert.Equal(t, []Index{{1, []int32{1}}}, gsa.LookupTextOrder([]int32{math.MinInt32}))
+
+	src32 := [][]int32{[]int32("abab"), []int32("bab")}
+	gsa, err = BuildGSA_32(src32)
+	assert.NoError(t, err)
+	assert.Equal(t, NewGSA_32(src32).sa, gsa.sa)
+	// The budget covers the concatenated text as well as its construction.
+	_, err = BuildGSA_32(src32, WithMemoryBudget(buildMemory[int32](gsa.text, 1)))
+	assert.ErrorIs(t, err, ErrTooLarge)
+	_, err = BuildGSA_32(src32, WithMemoryBudget(buildMemory[int32](gsa.text, 1)+int64(8*len(gsa.text))))
+	assert.NoError(t, err)
+}
+
+// naiveGSALookup finds occurrences of a non-empty pattern in each string in text order.
+// With anchored set, only occurrences at the start or at the end of strings count.
+func naiveGSALookup(src [][]int32, p []int32, atStart, atEnd bool) []Index {
+	res := []Index{}
+	for i, s := range src {
+		var occ []int32
+		for j := 0; j+len(p) <= len(s); j++ {
+			if (atStart && j > 0) || (atEnd && j+len(p) < len(s)) {
+				continue
+			}
+			if slices.Equal(s[j:j+len(p)], p) {
+				occ = append(occ, int32(j))
+			}
+		}
+		if len(occ) > 0 {
+			res = append(res, Index{int32(i), occ})
+		}
+	}
+	return res
+}
+
+func TestGSASeparator(t *testing.T) {
+	tests := map[string]struct {
+		src       [][]int32
+		sep, hole int32
+	}{
+		"least character": {
+			src: [][]int32{{1, 2, 3}, {0xDFFF, 0xE001}},
+			sep: 0, hole: 0,
+		},
+		"negative characters": {
+			src: [][]int32{[]int32("a\uE000b"), {-5, 'a'}, {-3}},
+			sep: -6, hole: -6,
+		},
+		"least int32 value": {
+			src: [][]int32{{math.MinInt32, math.MinInt32 + 1, math.MaxInt32}, {math.MinInt32 + 3, 'a', math.MinInt32}},
+			sep: math.MinInt32, hole: math.MinInt32 + 2,
+		},
+		"empty strings": {
+			src: [][]int32{{}, {}},
+			sep: math.MaxInt32 - 1, hole: math.MaxInt32 - 1,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			gsa := NewGSA_32(tc.src)
+			assert.Equal(t, tc.sep, gsa.sep)
+			assert.Equal(t, tc.hole, gsa.hole)
+			assert.Equal(t, tc.src, gsa.src)
+			for _, doc := range tc.src {
+				for i := 0; i < len(doc); i++ {
+					for j := i + 1; j <= len(doc); j++ {
+						p := doc[i:j]
+						assert.Equal(t, naiveGSALookup(tc.src, p, false, false), gsa.LookupTextOrder(p), p)
+						assert.Equal(t, naiveGSALookup(tc.src, p, true, false), gsa.LookupPrefix(p), p)
+						assert.Equal(t, naiveGSALookup(tc.src, p, false, true), gsa.LookupSuffix(p), p)
+					}
+				}
+			}
+			// Suffixes of the strings follow in lexicographical order.
+			var prev []int32
+			for _, suf := range gsa.Suffixes() {
+				assert.LessOrEqual(t, slices.Compare(prev, suf), 0)
+				prev = suf
+			}
+			// Common prefixes stop at the chosen separator.
+			assert.Equal(t, makeGSALCP(gsa, func(c int32) bool { return c == tc.sep }), gsa.LCP())
+
+			data, err := gsa.MarshalBinary()
+			assert.NoError(t, err)
+			var got GSA
+			assert.NoError(t, got.UnmarshalBinary(data))
+			assert.Equal(t, gsa.sep, got.sep)
+			assert.Equal(t, gsa.hole, got.hole)
+			assert.Equal(t, tc.src, got.src)
+			assert.Equal(t, gsa.LookupSuffix(tc.src[0]), got.LookupSuffix(tc.src[0]))
+		})
+	}
+	t.Run("random int32 data", func(t *testing.T) {
+		src := make([][]int32, 20)
+		for i := range src {
+			src[i] = genRandText_dense(50, 4)
+			for j := range src[i] {
+				src[i][j] = math.MinInt32 + src[i][j] - 1 // Characters around the least int32 value.
+			}
+		}
+		gsa, gsa64 := NewGSA_32(src), NewGSA64_32(src)
+		for i := 0; i+3 <= 50; i += 7 {
+			p := src[3][i : i+3]
+			assert.Equal(t, naiveGSALookup(src, p, false, false), gsa.LookupTextOrder(p), p)
+			assert.Equal(t, len(naiveGSALookup(src, p, false, false)), len(gsa64.LookupTextOrder(p)), p)
+		}
+	})
+	t.Run("patterns containing the separator", func(t *testing.T) {
+		gsa := NewGSA_32([][]int32{{'a'}, {'b', 'c'}})
+		// Patterns spanning the end of one string and the start of the next.
+		for _, p := range [][]int32{{'a', gsa.sep, 'b'}, {gsa.sep}, {gsa.sep, 'b'}, {'a', gsa.sep}, {'c', gsa.sep}, {gsa.sep - 1}} {
+			assert.Empty(t, gsa.LookupTextOrder(p), p)
+			assert.Empty(t, gsa.LookupPrefix(p), p)
+			assert.Empty(t, gsa.LookupSuffix(p), p)
+			assert.Empty(t, gsa.Lookup(p), p)
+			assert.Zero(t, gsa.Count(p), p)
+		}
+		// The hole matches nothing, though it is stored like the character below it.
+		gsa = NewGSA_32([][]int32{{math.MinInt32, 'b'}, {'b', 'c'}})
+		assert.Equal(t, int32(math.MinInt32+1), gsa.hole)
+		assert.Empty(t, gsa.Lookup([]int32{gsa.hole, 'b'}))
+		assert.Equal(t, []Occurrence[int32]{{0, 0}}, gsa.Lookup([]int32{math.MinInt32, 'b'}))
+	})
+}
+
 func TestLookup(t *testing.T) {
 	tests := map[string]struct {
 		text,
@@ -206,6 +1242,277 @@ func TestLookup(t *testing.T) {
 	}
 }
 
+func naiveBounds(text, sa, prefix []int32) (int, int) {
+	cmp := func(i int) int {
+		suf := text[sa[i]:]
+		c := slices.Compare(suf[:min(len(suf), len(prefix))], prefix)
+		if c == 0 && len(suf) < len(prefix) {
+			return -1
+		}
+		return c
+	}
+	l := sort.Search(len(sa), func(i int) bool { return cmp(i) >= 0 })
+	r := sort.Search(len(sa), func(i int) bool { return cmp(i) > 0 })
+	return l, r
+}
+
+func fibonacciText(n int) []int32 {
+	a, b := []int32{'b'}, []int32{'a'}
+	for len(b) < n {
+		a, b = b, append(slices.Clone(b), a...)
+	}
+	return b[:n]
+}
+
+func TestSearch(t *testing.T) {
+	texts := map[string][]int32{
+		"same characters": slices.Repeat([]int32{'a'}, 300),
+		"periodic":        slices.Repeat([]int32("abc"), 100),
+		"fibonacci":       fibonacciText(300),
+		"random":          genRandText_dense(300, 3),
+		"single":          {'a'},
+	}
+	for name, text := range texts {
+		t.Run(name, func(t *testing.T) {
+			sa := New(text)
+			lr := newLCPLR(sa.LCP())
+			var patterns [][]int32
+			for i := 0; i < len(text); i += 7 {
+				for _, m := range []int{1, 2, 5, 40, len(text)} {
+					p := text[i:min(i+m, len(text))]
+					patterns = append(patterns, p, append(slices.Clone(p), 'a'), append(slices.Clone(p), 'z'))
+					changed := slices.Clone(p)
+					changed[len(p)-1]++
+					patterns = append(patterns, changed)
+				}
+			}
+			for _, p := range patterns {
+				l, r := naiveBounds(text, sa.sa, p)
+				gotL, gotR := bounds(text, sa.sa, nil, nil, p)
+				assert.Equal(t, []int{l, r}, []int{gotL, gotR}, p)
+				gotL, gotR = bounds(text, sa.sa, lr, nil, p)
+				assert.Equal(t, []int{l, r}, []int{gotL, gotR}, p)
+			}
+		})
+	}
+	t.Run("prefix table", func(t *testing.T) {
+		texts := map[string][]int32{
+			"fibonacci": fibonacciText(500),
+			"random":    genRandText_dense(500, 5),
+			"wide":      genRandText_32(500),
+			"negative":  {-3, 7, -3, 7, 7, -3, math.MinInt32, math.MaxInt32, -3, 7},
+		}
+		for name, text := range texts {
+			sa := New(text)
+			for k := 1; k <= 4; k++ {
+				table := newPrefixTable(text, sa.sa, k)
+				assert.Equal(t, name == "fibonacci" || name == "random", table.start != nil, "%s k=%d", name, k)
+				for i := 0; i < len(text); i++ {
+					for m := 1; m <= 6 && i+m <= len(text); m++ {
+						p := text[i : i+m]
+						l, r := naiveBounds(text, sa.sa, p)
+						gotL, gotR := bounds(text, sa.sa, nil, table, p)
+						assert.Equal(t, []int{l, r}, []int{gotL, gotR}, "%s k=%d %v", name, k, p)
+						absent := append(slices.Clone(p), math.MaxInt32-1)
+						l, r = naiveBounds(text, sa.sa, absent)
+						gotL, gotR = bounds(text, sa.sa, nil, table, absent)
+						assert.Equal(t, []int{l, r}, []int{gotL, gotR}, "%s k=%d %v", name, k, absent)
+					}
+				}
+			}
+		}
+		sa := New(fibonacciText(1000))
+		exp := slices.Clone(sa.Lookup([]int32("abaab")))
+		sa.EnablePrefixTable(3)
+		assert.NotNil(t, sa.table.Load())
+		assert.Equal(t, exp, sa.Lookup([]int32("abaab")))
+		assert.Equal(t, len(exp), sa.Count([]int32("abaab")))
+		sa.EnablePrefixTable(0)
+		assert.Nil(t, sa.table.Load())
+	})
+	t.Run("enable", func(t *testing.T) {
+		text := fibonacciText(1000)
+		sa := New(text)
+		exp := slices.Clone(sa.Lookup(text[500:600]))
+		sa.EnableLCPSearch()
+		sa.EnableLCPSearch()
+		assert.NotNil(t, sa.lr.Load())
+		assert.Equal(t, exp, sa.Lookup(text[500:600]))
+		assert.Equal(t, len(exp), sa.Count(text[500:600]))
+		assert.Equal(t, []int32{}, sa.Lookup([]int32("abc")))
+	})
+}
+
+func TestLookupMany(t *testing.T) {
+	texts := map[string][]int32{
+		"fibonacci": fibonacciText(2000),
+		"random":    genRandText_dense(2000, 4),
+		"wide":      genRandText_32(2000),
+	}
+	for name, text := range texts {
+		patterns := [][]int32{nil, {}, {math.MinInt32}, {math.MaxInt32}}
+		for i := 0; i < 500; i++ {
+			pos, m := rand.Intn(len(text)), rand.Intn(12)
+			p := slices.Clone(text[pos:min(pos+m, len(text))])
+			if i%3 == 0 && len(p) > 0 {
+				p[len(p)-1]++
+			}
+			patterns = append(patterns, p)
+		}
+		patterns = append(patterns, patterns[10], patterns[20])
+		sa := New(text)
+		check := func(t *testing.T, workers int) {
+			res := sa.LookupMany(patterns, workers)
+			assert.Len(t, res, len(patterns))
+			for i, p := range patterns {
+				l, r := naiveBounds(text, sa.sa, p)
+				assert.Equal(t, Interval{l, r}, res[i], p)
+				assert.Equal(t, sa.Count(p), res[i].Len(), p)
+			}
+		}
+		t.Run(name, func(t *testing.T) {
+			check(t, 1)
+			check(t, 4)
+			sa.EnablePrefixTable(2)
+			check(t, 0)
+			sa.EnableLCPSearch()
+			check(t, 1)
+			sa.EnablePrefixTable(0)
+			check(t, 3)
+		})
+	}
+	assert.Empty(t, New(nil).LookupMany(nil, 1))
+	assert.Equal(t, []Interval{{0, 0}, {0, 0}}, New(nil).LookupMany([][]int32{{1}, nil}, 2))
+}
+
+func TestLocate(t *testing.T) {
+	banana := New([]int32("banana"))
+	tests := map[string]struct {
+		pattern                string
+		rank, matched          int
+		predecessor, successor int
+	}{
+		"empty":      {"", 0, 0, -1, 5},
+		"found":      {"ban", 3, 3, 1, 0},
+		"partial":    {"anx", 3, 2, 1, 0},
+		"before all": {"0", 0, 0, -1, 5},
+		"after all":  {"z", 6, 0, 2, -1},
+		"too long":   {"nanas", 6, 4, 2, -1},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			p := []int32(tc.pattern)
+			rank, matched := banana.Locate(p)
+			assert.Equal(t, tc.rank, rank)
+			assert.Equal(t, tc.matched, matched)
+			assert.Equal(t, tc.predecessor, banana.Predecessor(p))
+			assert.Equal(t, tc.successor, banana.Successor(p))
+		})
+	}
+	rank, matched := New(nil).Locate([]int32{1})
+	assert.Equal(t, 0, rank)
+	assert.Equal(t, 0, matched)
+	assert.Equal(t, -1, New(nil).Predecessor(nil))
+	assert.Equal(t, -1, New(nil).Successor(nil))
+
+	text := genRandText_dense(1000, 4)
+	sa := New(text)
+	check := func(t *testing.T) {
+		for i := 0; i < 300; i++ {
+			pos, m := rand.Intn(len(text)), rand.Intn(12)
+			p := slices.Clone(text[pos:min(pos+m, len(text))])
+			if i%2 == 0 && len(p) > 0 {
+				p[rand.Intn(len(p))] = rand.Int31n(6)
+			}
+			l, _ := naiveBounds(text, sa.sa, p)
+			longest := 0
+			for j := range text {
+				_, k := comparePrefix(text[j:], p, 0)
+				longest = max(longest, k)
+			}
+			rank, matched := sa.Locate(p)
+			assert.Equal(t, l, rank, p)
+			assert.Equal(t, longest, matched, p)
+			pred, succ := -1, -1
+			if l > 0 {
+				pred = int(sa.sa[l-1])
+			}
+			if l < len(text) {
+				succ = int(sa.sa[l])
+			}
+			assert.Equal(t, pred, sa.Predecessor(p), p)
+			assert.Equal(t, succ, sa.Successor(p), p)
+		}
+	}
+	t.Run("random", check)
+	sa.EnablePrefixTable(3)
+	t.Run("prefix table", check)
+	sa.EnableLCPSearch()
+	sa.EnablePrefixTable(0)
+	t.Run("lcp search", check)
+}
+
+func TestRange(t *testing.T) {
+	inRange := func(s, lo, hi []int32) bool {
+		return slices.Compare(s, lo) >= 0 && (len(hi) == 0 || slices.Compare(s, hi) < 0)
+	}
+	// Alphabets with characters below the separator and the hole in bounds only.
+	for _, alphabet := range [][]int32{{10, 11, 13, 14}, {math.MinInt32, math.MinInt32 + 1, math.MinInt32 + 3, math.MinInt32 + 4}} {
+		randString := func(n int) []int32 {
+			s := make([]int32, rand.Intn(n+1))
+			for i := range s {
+				s[i] = alphabet[rand.Intn(len(alphabet))]
+			}
+			return s
+		}
+		src := make([][]int32, 200)
+		for i := range src {
+			src[i] = randString(6)
+		}
+		text := slices.Concat(src...)
+		sa, gsa := New(text), NewGSA_32(src)
+		hole := alphabet[1] + 1
+		bounds := [][]int32{nil, {hole}, {alphabet[0] - 1}, {alphabet[1], hole}, {alphabet[2], hole, alphabet[0]}, {alphabet[0], alphabet[0] - 1, alphabet[3]}}
+		for i := 0; i < 300; i++ {
+			bounds = append(bounds, randString(4))
+		}
+		for i := 0; i < 1000; i++ {
+			lo, hi := bounds[rand.Intn(len(bounds))], bounds[rand.Intn(len(bounds))]
+			expected := []int32{}
+			for _, j := range sa.sa {
+				if inRange(text[j:], lo, hi) {
+					expected = append(expected, j)
+				}
+			}
+			assert.Equal(t, expected, sa.Range(lo, hi), "%v %v", lo, hi)
+			expectedOcc := []Occurrence[int32]{}
+			for str, s := range src {
+				for off := range s {
+					if inRange(s[off:], lo, hi) {
+						expectedOcc = append(expectedOcc, Occurrence[int32]{int32(str), int32(off)})
+					}
+				}
+			}
+			occ := gsa.Range(lo, hi)
+			assert.ElementsMatch(t, expectedOcc, occ, "%v %v", lo, hi)
+			for k := 1; k < len(occ); k++ {
+				prev := src[occ[k-1].String][occ[k-1].Offset:]
+				curr := src[occ[k].String][occ[k].Offset:]
+				assert.LessOrEqual(t, slices.Compare(prev, curr), 0)
+			}
+		}
+	}
+
+	words := NewGSA([]string{"fog", "foo", "food", "fop", "for"})
+	expected := []Occurrence[int32]{{1, 0}, {2, 0}}
+	assert.Equal(t, expected, words.Range([]int32("foo"), []int32("fop")))
+	assert.Equal(t, expected, NewGSABytes([][]byte{[]byte("fog"), []byte("foo"), []byte("food"), []byte("fop"), []byte("for")}).
+		Range([]byte("foo"), []byte("fop")))
+	assert.Equal(t, []int32{0}, New([]int32("fofop")).Range([]int32("fo"), []int32("fop")))
+	assert.Empty(t, New(nil).Range(nil, nil))
+	assert.Empty(t, NewGSA([]string{""}).Range(nil, nil))
+}
+
 func TestGSA(t *testing.T) {
 	tests := map[string]struct {
 		text_32 [][]int32
@@ -601,6 +1908,200 @@ func TestGSAWithSingleText(t *testing.T) {
 	}
 }
 
+func groupOccurrences(occ []Occurrence[int32]) []Index {
+	occ = slices.Clone(occ)
+	slices.SortFunc(occ, func(a, b Occurrence[int32]) int {
+		if a.String != b.String {
+			return int(a.String - b.String)
+		}
+		return int(a.Offset - b.Offset)
+	})
+	res := []Index{}
+	for _, o := range occ {
+		if len(res) == 0 || res[len(res)-1].String != o.String {
+			res = append(res, Index{String: o.String})
+		}
+		res[len(res)-1].Occurences = append(res[len(res)-1].Occurences, o.Offset)
+	}
+	return res
+}
+
+func TestGSALookupSAOrder(t *testing.T) {
+	src := [][]int32{
+		[]int32("abzababab"),
+		[]int32(""),
+		[]int32("babaxyzab"),
+		[]int32("abababab"),
+	}
+	gsa := NewGSA_32(src)
+	assert.Equal(t, []Occurrence[int32]{{3, 6}, {0, 7}, {2, 7}, {3, 4}, {0, 5}, {3, 2}, {0, 3}, {3, 0}, {2, 1}, {0, 0}},
+		gsa.Lookup([]int32("ab")))
+	assert.Equal(t, []Occurrence[int32]{}, gsa.Lookup([]int32("abc")))
+	assert.Equal(t, []Occurrence[int32]{}, gsa.Lookup([]int32{'b', gsa.sep, 'a'}))
+	assert.Len(t, gsa.Lookup(nil), totalLen(src))
+	// The end of "ab" sorts before "abc" whatever strings follow.
+	assert.Equal(t, []Occurrence[int32]{{0, 0}, {1, 0}}, NewGSA([]string{"ab", "abc"}).Lookup([]int32("a")))
+	assert.Equal(t, []Occurrence[int32]{{1, 0}, {0, 0}}, NewGSA([]string{"abc", "ab"}).Lookup([]int32("a")))
+
+	random := make([][]int32, 20)
+	for i := range random {
+		random[i] = genRandText_dense(rand.Intn(50), 3)
+	}
+	for _, src := range [][][]int32{src, random} {
+		gsa := NewGSA_32(src)
+		for _, doc := range src {
+			for i := 0; i < len(doc); i++ {
+				for j := i + 1; j <= len(doc) && j < i+4; j++ {
+					p := doc[i:j]
+					occ := gsa.Lookup(p)
+					assert.Equal(t, naiveGSALookup(src, p, false, false), groupOccurrences(occ), p)
+					// Occurrences follow the order of the suffixes of the strings starting at them.
+					for k := 1; k < len(occ); k++ {
+						prev := src[occ[k-1].String][occ[k-1].Offset:]
+						curr := src[occ[k].String][occ[k].Offset:]
+						assert.LessOrEqual(t, slices.Compare(prev, curr), 0)
+					}
+				}
+			}
+		}
+	}
+
+	bytesGSA := NewGSABytes([][]byte{[]byte("abzababab"), nil, []byte("babaxyzab"), []byte("abababab")})
+	assert.Equal(t, gsa.Lookup([]int32("ab")), bytesGSA.Lookup([]byte("ab")))
+}
+
+func TestCount(t *testing.T) {
+	text := genRandText_dense(3000, 4)
+	sa := New(text)
+	for _, p := range [][]int32{nil, {0}, {1, 2}, {3, 3, 3}, {4}, text[100:110]} {
+		assert.Equal(t, len(sa.Lookup(p)), sa.Count(p), p)
+	}
+
+	src := make([][]int32, 30)
+	for i := range src {
+		src[i] = genRandText_dense(rand.Intn(40), 3)
+	}
+	src[3] = nil
+	gsa := NewGSA_32(src)
+	patterns := [][]int32{{0}, {1, 2}, {2, 2, 2}, {0, 1, 2, 0}, {3}, {1, gsa.sep}}
+	for _, p := range patterns {
+		assert.Equal(t, len(gsa.Lookup(p)), gsa.Count(p), p)
+		assert.Equal(t, len(naiveGSALookup(src, p, false, false)), gsa.DocumentCount(p), p)
+	}
+	assert.Equal(t, len(gsa.Lookup(nil)), gsa.Count(nil))
+	var nonEmpty int
+	for _, s := range src {
+		if len(s) > 0 {
+			nonEmpty++
+		}
+	}
+	assert.Equal(t, nonEmpty, gsa.DocumentCount(nil))
+	assert.Zero(t, new(GSA).DocumentCount([]int32{1}))
+
+	bytesGSA := NewGSABytes([][]byte{[]byte("banana"), []byte("ananas"), []byte("bandana")})
+	assert.Equal(t, 6, bytesGSA.Count([]byte("an")))
+	assert.Equal(t, 3, bytesGSA.DocumentCount([]byte("an")))
+	assert.Equal(t, 2, bytesGSA.DocumentCount([]byte("ban")))
+
+	allocs := testing.AllocsPerRun(10, func() {
+		sa.Count([]int32{1, 2})
+		gsa.Count([]int32{1, 2})
+		gsa.DocumentCount([]int32{1, 2})
+		bytesGSA.DocumentCount([]byte("an"))
+	})
+	assert.Zero(t, allocs)
+}
+
+func TestIterators(t *testing.T) {
+	text := genRandText_dense(2000, 4)
+	sa := New(text)
+	for _, p := range [][]int32{nil, {0}, {1, 2}, {4}, text[100:110]} {
+		assert.Equal(t, sa.Lookup(p), slices.AppendSeq([]int32{}, sa.Occurrences(p)), p)
+	}
+	var k int
+	for p, suf := range sa.Suffixes() {
+		assert.Equal(t, sa.sa[k], p)
+		assert.Equal(t, text[p:], suf)
+		k++
+	}
+	assert.Equal(t, len(text), k)
+
+	src := make([][]int32, 30)
+	for i := range src {
+		src[i] = genRandText_dense(rand.Intn(40), 3)
+	}
+	gsa := NewGSA_32(src)
+	for _, p := range [][]int32{nil, {0}, {1, 2}, {2, 2, 2}, {3}, {1, gsa.sep}} {
+		assert.Equal(t, gsa.Lookup(p), slices.AppendSeq([]Occurrence[int32]{}, gsa.Occurrences(p)), p)
+		docs := slices.Collect(gsa.Documents(p))
+		assert.Len(t, docs, gsa.DocumentCount(p), p)
+		var exp []int32
+		for _, idx := range naiveGSALookup(src, p, false, false) {
+			exp = append(exp, idx.String)
+		}
+		if len(p) > 0 {
+			assert.ElementsMatch(t, exp, docs, p)
+		}
+	}
+	var occ []Occurrence[int32]
+	for o, suf := range gsa.Suffixes() {
+		assert.Equal(t, src[o.String][o.Offset:], suf)
+		occ = append(occ, o)
+	}
+	assert.Equal(t, gsa.Lookup(nil), occ)
+
+	t.Run("early stop", func(t *testing.T) {
+		for range sa.Occurrences(nil) {
+			break
+		}
+		for range sa.Suffixes() {
+			break
+		}
+		for range gsa.Occurrences(nil) {
+			break
+		}
+		for range gsa.Documents(nil) {
+			break
+		}
+		for range gsa.Suffixes() {
+			break
+		}
+	})
+
+	bytesGSA := NewGSABytes([][]byte{[]byte("banana"), []byte("ananas"), []byte("bandana")})
+	assert.Equal(t, bytesGSA.Lookup([]byte("an")), slices.Collect(bytesGSA.Occurrences([]byte("an"))))
+	assert.ElementsMatch(t, []int32{0, 2}, slices.Collect(bytesGSA.Documents([]byte("ban"))))
+}
+
+func TestGSAConcurrent(t *testing.T) {
+	src := []string{"abzababab", "babaxyzab", "abababab", "xyz", "bab"}
+	gsa := NewGSA(src)
+	patterns := [][]int32{[]int32("ab"), []int32("bab"), []int32("xyz"), []int32("q"), {}}
+	type results struct{ text, prefix, suffix []Index }
+	query := func(p []int32) results {
+		return results{gsa.LookupTextOrder(p), gsa.LookupPrefix(p), gsa.LookupSuffix(p)}
+	}
+	exp := make([]results, len(patterns))
+	for i, p := range patterns {
+		exp[i] = query(p)
+	}
+	// Results of earlier calls are not overwritten by later ones.
+	assert.Equal(t, query(patterns[0]), exp[0])
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for k := 0; k < 100; k++ {
+				i := (g + k) % len(patterns)
+				assert.Equal(t, exp[i], query(patterns[i]))
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
 func BenchmarkGSALookup(b *testing.B) {
 	tests := map[string]struct {
 		text   [][]int32
@@ -771,13 +2272,122 @@ func BenchmarkSAIS(b *testing.B) {
 		{"ACGTGCCTAGCCTACCGTGCC", []int32("ACGTGCCTAGCCTACCGTGCC")},
 		{"long random string", genRandText_32(10000)},
 		{"long random string 8", genRandText_8_32(10000)},
+		{"random string 32 100k", genRandText_32(100000)},
+		{"repeated random string 32 100k", slices.Repeat(genRandText_32(1000), 100)},
+		{"dense large alphabet 100k", genRandText_dense(100000, 5000)},
 	}
 
 	for _, tt := range tests {
 		b.Run(tt.name, func(b *testing.B) {
 			for i := 0; i < b.N; i++ {
-				sais(tt.input_32)
+				sais[int32](tt.input_32)
+			}
+		})
+	}
+}
+
+func BenchmarkNewParallel(b *testing.B) {
+	text := genRandText_8_32(1000000)
+	b.Run("sequential", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			New(text)
+		}
+	})
+	b.Run("parallel", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			New(text, WithParallelism(0))
+		}
+	})
+}
+
+func BenchmarkBuilder(b *testing.B) {
+	text := genRandText_8_32(1000)
+	b.Run("new", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			New(text)
+		}
+	})
+	b.Run("builder", func(b *testing.B) {
+		b.ReportAllocs()
+		var builder Builder
+		var dst []int32
+		for i := 0; i < b.N; i++ {
+			dst = builder.Build(text, dst)
+		}
+	})
+}
+
+func BenchmarkLookup(b *testing.B) {
+	texts := map[string][]int32{
+		"random":    genRandText_32(1000000),
+		"fibonacci": fibonacciText(1000000),
+	}
+	for name, text := range texts {
+		sa := New(text)
+		patterns := make([][]int32, 100)
+		for i := range patterns {
+			pos := rand.Intn(len(text) - 1000)
+			patterns[i] = text[pos : pos+1000]
+		}
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				sa.Count(patterns[i%len(patterns)])
+			}
+		})
+		b.Run(name+" lcp", func(b *testing.B) {
+			sa.EnableLCPSearch()
+			for i := 0; i < b.N; i++ {
+				sa.Count(patterns[i%len(patterns)])
+			}
+		})
+	}
+}
+
+func BenchmarkLookupShort(b *testing.B) {
+	texts := map[string][]int32{
+		"dna":  genRandText_dense(1000000, 4),
+		"wide": genRandText_32(1000000),
+	}
+	for name, text := range texts {
+		sa := New(text)
+		patterns := make([][]int32, 100)
+		for i := range patterns {
+			pos := rand.Intn(len(text) - 3)
+			patterns[i] = text[pos : pos+3]
+		}
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				sa.Count(patterns[i%len(patterns)])
+			}
+		})
+		b.Run(name+" table", func(b *testing.B) {
+			sa.EnablePrefixTable(3)
+			for i := 0; i < b.N; i++ {
+				sa.Count(patterns[i%len(patterns)])
 			}
 		})
 	}
 }
+
+func BenchmarkLookupMany(b *testing.B) {
+	text := genRandText_dense(1000000, 4)
+	sa := New(text)
+	patterns := make([][]int32, 10000)
+	for i := range patterns {
+		pos := rand.Intn(len(text) - 20)
+		patterns[i] = text[pos : pos+20]
+	}
+	b.Run("loop", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, p := range patterns {
+				sa.Count(p)
+			}
+		}
+	})
+	b.Run("many", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			sa.LookupMany(patterns, 1)
+		}
+	})
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2025 Nikita Kamenev
+// Licensed under the MIT License. See LICENSE file in the project root for details.
+package suffixarr
+
+import "math/bits"
+
+// bitVector is a fixed-size bit array with constant-time rank support.
+// Cumulative counts are stored for every block of 8 words, so the rank
+// directory adds 1/16 of the bit array size.
+type bitVector struct {
+	bits  []uint64
+	ranks []uint32 // Number of set bits before each block of 8 words.
+}
+
+// newBitVector creates a bit vector of n unset bits.
+func newBitVector(n int) bitVector {
+	return bitVector{bits: make([]uint64, (n+63)/64)}
+}
+
+// set sets the bit at position i. The rank directory must be rebuilt afterwards.
+func (b *bitVector) set(i int) {
+	b.bits[i>>6] |= 1 << (i & 63)
+}
+
+// get reports whether the bit at position i is set.
+func (b *bitVector) get(i int) bool {
+	return b.bits[i>>6]&(1<<(i&63)) != 0
+}
+
+// build computes the rank directory once all bits are set.
+func (b *bitVector) build() {
+	b.ranks = make([]uint32, len(b.bits)/8+1)
+	var n uint32
+	for i, w := range b.bits {
+		if i&7 == 0 {
+			b.ranks[i>>3] = n
+		}
+		n += uint32(bits.OnesCount64(w))
+	}
+	if len(b.bits)&7 == 0 {
+		b.ranks[len(b.bits)>>3] = n
+	}
+}
+
+// rank1 returns the number of set bits in positions [0, i).
+func (b *bitVector) rank1(i int) int {
+	w := i >> 6
+	r := int(b.ranks[w>>3])
+	for j := w &^ 7; j < w; j++ {
+		r += bits.OnesCount64(b.bits[j])
+	}
+	if i&63 != 0 {
+		r += bits.OnesCount64(b.bits[w] & (1<<(i&63) - 1))
+	}
+	return r
+}
+
+// waveletMatrix stores a sequence of small integer codes as one bit vector per
+// code bit, supporting access and rank in O(log sigma) independent of the length.
+type waveletMatrix struct {
+	levels []bitVector
+	zeros  []int   // Number of zero bits on each level.
+	start  []int32 // First position of each code after the last level.
+}
+
+// newWaveletMatrix builds a wavelet matrix over codes in range [0, sigma).
+// The codes slice is used as scratch space and is overwritten.
+func newWaveletMatrix(codes []int32, sigma int) *waveletMatrix {
+	depth := max(bits.Len(uint(sigma-1)), 1)
+	wm := &waveletMatrix{
+		levels: make([]bitVector, depth),
+		zeros:  make([]int, depth),
+		start:  make([]int32, sigma),
+	}
+	cur, next := codes, make([]int32, len(codes))
+	for l := 0; l < depth; l++ {
+		shift := depth - 1 - l
+		bv := newBitVector(len(cur))
+		var z int
+		// Mark one bits of this level and count zeros.
+		for i, c := range cur {
+			if c>>shift&1 == 1 {
+				bv.set(i)
+			} else {
+				z++
+			}
+		}
+		bv.build()
+		// Stable partition: zeros first, then ones.
+		lo, hi := 0, z
+		for _, c := range cur {
+			if c>>shift&1 == 1 {
+				next[hi] = c
+				hi++
+			} else {
+				next[lo] = c
+				lo++
+			}
+		}
+		wm.levels[l], wm.zeros[l] = bv, z
+		cur, next = next, cur
+	}
+	// Equal codes are contiguous after the last level; record where each starts.
+	for i := len(cur) - 1; i >= 0; i-- {
+		wm.start[cur[i]] = int32(i)
+	}
+	return wm
+}
+
+// access returns the code at position i together with its rank,
+// the number of equal codes in positions [0, i).
+func (wm *waveletMatrix) access(i int) (c int32, r int) {
+	for l := range wm.levels {
+		bv := &wm.levels[l]
+		if bv.get(i) {
+			c = c<<1 | 1
+			i = wm.zeros[l] + bv.rank1(i)
+		} else {
+			c <<= 1
+			i -= bv.rank1(i)
+		}
+	}
+	return c, i - int(wm.start[c])
+}
+
+// rank returns the number of occurrences of code c in positions [0, i).
+func (wm *waveletMatrix) rank(c int32, i int) int {
+	depth := len(wm.levels)
+	for l := range wm.levels {
+		bv := &wm.levels[l]
+		if c>>(depth-1-l)&1 == 1 {
+			i = wm.zeros[l] + bv.rank1(i)
+		} else {
+			i -= bv.rank1(i)
+		}
+	}
+	return i - int(wm.start[c])
+}